@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+// newAdditionalProperty returns property of additionalProperties values. returns nil
+// if schema doesn't have additionalProperties schema
+func newAdditionalProperty(name string, sc *schema.Schema, root *schema.Schema, exp expansion) (*Property, error) {
+	if sc.AdditionalProperties == nil || sc.AdditionalProperties.Schema == nil {
+		return nil, nil
+	}
+	ap, err := newProperty(name, sc.AdditionalProperties.Schema, sc, root, exp)
+	if err != nil {
+		var errs ErrorList
+		errs.add(err, "additionalProperties")
+		return nil, errs
+	}
+	ap.Required = true
+	ap.InlineProperties = sortProperties(ap.InlineProperties)
+	return ap, nil
+}
+
+// mapType returns go map type of additionalProperties values
+func mapType(value *Property, op FormatOption) string {
+	t := value.GoType(op)
+	if t == "" {
+		t = "interface{}"
+	}
+	return "map[string]" + t
+}
+
+// additionalPropertiesField returns catch-all map field of struct
+func additionalPropertiesField(ap *Property, op FormatOption) string {
+	if ap == nil {
+		return ""
+	}
+	return fmt.Sprintf("AdditionalProperties %s `json:\"-\"`\n", mapType(ap, op))
+}
+
+// additionalPropertiesFuncs returns go functions (un)marshalling struct with catch-all map
+func additionalPropertiesFuncs(name string, props []*Property, ap *Property, op FormatOption) []byte {
+	if ap == nil {
+		return []byte("")
+	}
+	var keys []string
+	for _, p := range props {
+		keys = append(keys, fmt.Sprintf("%q: true", p.Name))
+	}
+	valueType := strings.TrimPrefix(mapType(ap, op), "map[string]")
+
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Parse(`
+	var {{ .Keys }} = map[string]bool{
+		{{- range .Props }}
+		{{ . }},
+		{{- end }}
+	}
+
+	// MarshalJSON encodes {{ .Name }} with additional properties
+	func (r {{ .Name }}) MarshalJSON() ([]byte, error) {
+		type alias {{ .Name }}
+		b, err := json.Marshal(alias(r))
+		if err != nil || len(r.AdditionalProperties) == 0 {
+			return b, err
+		}
+		m := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+		for k, v := range r.AdditionalProperties {
+			if {{ .Keys }}[k] {
+				continue
+			}
+			vb, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = vb
+		}
+		return json.Marshal(m)
+	}
+
+	// UnmarshalJSON decodes {{ .Name }} with additional properties
+	func (r *{{ .Name }}) UnmarshalJSON(b []byte) error {
+		type alias {{ .Name }}
+		var a alias
+		if err := json.Unmarshal(b, &a); err != nil {
+			return err
+		}
+		m := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(b, &m); err != nil {
+			return err
+		}
+		for k, v := range m {
+			if {{ .Keys }}[k] {
+				continue
+			}
+			var val {{ .ValueType }}
+			if err := json.Unmarshal(v, &val); err != nil {
+				return err
+			}
+			if a.AdditionalProperties == nil {
+				a.AdditionalProperties = make({{ .MapType }})
+			}
+			a.AdditionalProperties[k] = val
+		}
+		*r = {{ .Name }}(a)
+		return nil
+	}
+	`)
+	var src bytes.Buffer
+	tmpl.Execute(&src, map[string]interface{}{
+		"Name":      name,
+		"Keys":      strings.ToLower(name[:1]) + name[1:] + "KnownProperties",
+		"Props":     keys,
+		"ValueType": valueType,
+		"MapType":   mapType(ap, op),
+	})
+	return src.Bytes()
+}
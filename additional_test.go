@@ -0,0 +1,176 @@
+package main
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func TestAdditionalPropertiesField(t *testing.T) {
+	cases := []struct {
+		Prop     Property
+		Expected string
+	}{
+		{
+			Prop: Property{
+				Name:     "labels",
+				Types:    []schema.PrimitiveType{schema.ObjectType},
+				PropType: PropTypeObject,
+				Required: true,
+				AdditionalProperties: &Property{
+					Name:     "labels",
+					Types:    []schema.PrimitiveType{schema.StringType},
+					Required: true,
+				},
+			},
+			Expected: "Labels map[string]string `json:\"labels\"`",
+		},
+		{
+			Prop: Property{
+				Name:     "users",
+				Types:    []schema.PrimitiveType{schema.ObjectType},
+				PropType: PropTypeObject,
+				Required: true,
+				AdditionalProperties: &Property{
+					Name:      "users",
+					Types:     []schema.PrimitiveType{schema.ObjectType},
+					PropType:  PropTypeObject,
+					Reference: "#/definitions/user",
+					Required:  true,
+				},
+			},
+			Expected: "Users map[string]*User `json:\"users\"`",
+		},
+		{
+			Prop: Property{
+				Name:     "extra",
+				Types:    []schema.PrimitiveType{schema.ObjectType},
+				PropType: PropTypeObject,
+				AdditionalProperties: &Property{
+					Name:     "extra",
+					Required: true,
+				},
+			},
+			Expected: "Extra map[string]interface{} `json:\"extra,omitempty\"`",
+		},
+	}
+	for _, c := range cases {
+		if f := c.Prop.Field(FormatOption{}); string(f) != c.Expected {
+			t.Errorf("want %s got %s", c.Expected, f)
+		}
+	}
+}
+
+func TestResourceStructAdditionalProperties(t *testing.T) {
+	res := Resource{
+		Name: "task",
+		Properties: []*Property{
+			{
+				Name:     "id",
+				Types:    []schema.PrimitiveType{schema.StringType},
+				Required: true,
+			},
+		},
+		AdditionalProperties: &Property{
+			Name:     "additionalProperties",
+			Types:    []schema.PrimitiveType{schema.IntegerType},
+			Required: true,
+		},
+	}
+	ss, err := format.Source(res.Struct(FormatOption{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%s", ss)
+}
+
+func TestNestedAdditionalProperties(t *testing.T) {
+	src := `{
+  "definitions": {
+    "task": {
+      "type": "object",
+      "properties": {
+        "meta": {
+          "type": "object",
+          "properties": {"a": {"type": "string"}},
+          "additionalProperties": {"type": "string"}
+        },
+        "notes": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "properties": {"body": {"type": "string"}},
+            "additionalProperties": {"type": "integer"}
+          }
+        }
+      },
+      "required": ["meta"]
+    }
+  }
+}`
+	p, err := ReadParser(strings.NewReader(src), "model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := p.ParseResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := res["task"]
+	op := FormatOption{}
+	types := map[string]string{"meta": "TaskMeta", "notes": "[]TaskNotes"}
+	for _, pr := range task.Properties {
+		if pr.GoType(op) != types[pr.Name] {
+			t.Errorf("%s: want %s got %s", pr.Name, types[pr.Name], pr.GoType(op))
+		}
+	}
+
+	structs := make(map[string]*Property)
+	if err := collectTypes(task.Properties, make(map[string]*Enum), make(map[string]*Union), structs); err != nil {
+		t.Fatal(err)
+	}
+	if len(structs) != 2 {
+		t.Fatalf("want task_meta and task_notes got %v", structs)
+	}
+	ss, err := format.Source(structs["task_meta"].RecursiveStruct(op))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "// TaskMeta struct for task_meta with additional properties\n" +
+		"type TaskMeta struct {\n" +
+		"\tA                    string            `json:\"a,omitempty\"`\n" +
+		"\tAdditionalProperties map[string]string `json:\"-\"`\n" +
+		"}\n"
+	if !strings.HasPrefix(string(ss), expected) {
+		t.Errorf("want %s got %s", expected, ss)
+	}
+
+	gen := []byte("package main\n\nimport (\n\"encoding/json\"\n\"fmt\"\n)\n")
+	gen = append(gen, task.Struct(op)...)
+	for _, n := range []string{"task_meta", "task_notes"} {
+		gen = append(gen, structs[n].RecursiveStruct(op)...)
+	}
+	gen = append(gen, []byte(`
+func main() {
+	var task Task
+	in := `+"`"+`{"meta":{"a":"x","b":"y"},"notes":[{"body":"z","n":1}]}`+"`"+`
+	if err := json.Unmarshal([]byte(in), &task); err != nil {
+		panic(err)
+	}
+	fmt.Println(task.Meta.A, task.Meta.AdditionalProperties, task.Notes[0].AdditionalProperties)
+	b, err := json.Marshal(task)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(b))
+}
+`)...)
+	out := runGo(t, gen)
+	expectedOut := "x map[b:y] map[n:1]\n" +
+		`{"meta":{"a":"x","b":"y"},"notes":[{"body":"z","n":1}]}` + "\n"
+	if out != expectedOut {
+		t.Errorf("want %s got %s", expectedOut, out)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/achiku/varfmt"
+	schema "github.com/lestrrat-go/jsschema"
+	"github.com/pkg/errors"
+)
+
+// errorResource returns resource designated as error by name, or by x-go-error: true.
+// returns nil if no resource is designated
+func errorResource(resources map[string]Resource, name string) (*Resource, error) {
+	if name != "" {
+		rs, ok := resources[name]
+		if !ok {
+			return nil, errors.Errorf("error resource not found: %s", name)
+		}
+		return &rs, nil
+	}
+	var found *Resource
+	for id, rs := range resources {
+		if rs.Schema == nil {
+			continue
+		}
+		if v, ok := rs.Schema.Extras["x-go-error"].(bool); !ok || !v {
+			continue
+		}
+		if found != nil {
+			return nil, errors.Errorf("x-go-error is set to multiple resources: %s, %s", found.Name, id)
+		}
+		rs := rs
+		found = &rs
+	}
+	return found, nil
+}
+
+// optionalValue scalar value of go type Type, which is Base or Base wrapped by
+// optional mode
+type optionalValue struct {
+	Type     string
+	Base     string
+	Optional Optional
+}
+
+// newOptionalValue returns value of go type t if it is base, or base wrapped by optional mode
+func newOptionalValue(t, base string, o Optional) (optionalValue, bool) {
+	if t != base && (o == OptionalNone || t != o.wrap(base)) {
+		return optionalValue{}, false
+	}
+	return optionalValue{Type: t, Base: base, Optional: o}, true
+}
+
+// get returns statements declaring local, and expression of unwrapped value of x
+func (v optionalValue) get(x, local string) ([]string, string) {
+	switch {
+	case v.Type == v.Base:
+		return nil, x
+	case v.Optional == OptionalGeneric:
+		return nil, x + ".Value"
+	case strings.HasPrefix(v.Type, "*"):
+		return []string{
+			fmt.Sprintf("var %s %s", local, v.Base),
+			fmt.Sprintf("if %s != nil {", x),
+			fmt.Sprintf("	%s = *%s", local, x),
+			"}",
+		}, local
+	default:
+		// null.String and NullString
+		return nil, x + ".String"
+	}
+}
+
+// set returns statements assigning val to x, declaring local if needed
+func (v optionalValue) set(x, val, local string) []string {
+	switch {
+	case v.Type == v.Base:
+		return []string{fmt.Sprintf("%s = %s", x, val)}
+	case v.Optional == OptionalGeneric:
+		return []string{fmt.Sprintf("%s = NewOptional(%s)", x, val)}
+	case strings.HasPrefix(v.Type, "*"):
+		return []string{fmt.Sprintf("%s := %s", local, val), fmt.Sprintf("%s = &%s", x, local)}
+	case v.Optional == OptionalNull:
+		return []string{fmt.Sprintf("%s = null.StringFrom(%s)", x, val)}
+	default:
+		return []string{fmt.Sprintf("%s = NullString{NullString: sql.NullString{String: %s, Valid: true}}", x, val)}
+	}
+}
+
+// fieldErrors array property of inline objects having name and message
+type fieldErrors struct {
+	Property *Property
+	Name     optionalValue
+	Message  optionalValue
+}
+
+// newFieldErrors returns array property of inline objects having name and message
+// of string, or nil if not found
+func newFieldErrors(rs *Resource, op FormatOption) *fieldErrors {
+	for _, p := range rs.Properties {
+		if p.PropType != PropTypeArray || len(p.InlineProperties) == 0 {
+			continue
+		}
+		fe := fieldErrors{Property: p}
+		var name, message bool
+		for _, ip := range p.InlineProperties {
+			v, ok := newOptionalValue(ip.GoType(op), "string", op.Optional)
+			if !ok {
+				continue
+			}
+			switch ip.Name {
+			case "name":
+				fe.Name, name = v, true
+			case "message":
+				fe.Message, message = v, true
+			}
+		}
+		if name && message {
+			return &fe
+		}
+	}
+	return nil
+}
+
+// ErrorShape JSON keys and values of error resource
+type ErrorShape struct {
+	CodeKey    string
+	Code       string
+	MessageKey string
+}
+
+// NewErrorShape returns keys of code and message properties of error resource, and
+// code of validation failure. message key falls back to "message"
+func NewErrorShape(rs *Resource) ErrorShape {
+	shape := ErrorShape{MessageKey: "message"}
+	if rs == nil {
+		return shape
+	}
+	for _, p := range rs.Properties {
+		if p.PropType != PropTypeScalar || !p.Types.Contains(schema.StringType) {
+			continue
+		}
+		switch p.Name {
+		case "code", "id":
+			shape.CodeKey = p.Name
+			shape.Code = "invalid_request"
+			if p.Enum != nil && len(p.Enum.Values) != 0 {
+				shape.Code = fmt.Sprint(p.Enum.Values[0])
+				for _, v := range p.Enum.Values {
+					if strings.HasPrefix(fmt.Sprint(v), "invalid") {
+						shape.Code = fmt.Sprint(v)
+						break
+					}
+				}
+			}
+		case "detail", "message":
+			shape.MessageKey = p.Name
+		}
+	}
+	return shape
+}
+
+// ErrorFuncs returns go methods implementing error by error resource, and function
+// building it from validation failure
+func ErrorFuncs(rs *Resource, op FormatOption) []byte {
+	if rs == nil {
+		return []byte("")
+	}
+	shape := NewErrorShape(rs)
+	data := map[string]interface{}{
+		"Name": varfmt.PublicVarName(normalize(rs.Name)),
+	}
+	for _, p := range rs.Properties {
+		t := p.GoType(op)
+		x := "e." + varfmt.PublicVarName(normalize(p.Name))
+		switch p.Name {
+		case shape.CodeKey:
+			base, code := "string", strconv.Quote(shape.Code)
+			if p.Enum != nil {
+				base, code = p.Enum.TypeName(), p.Enum.ConstName(shape.Code)
+			}
+			if v, ok := newOptionalValue(t, base, op.Optional); ok {
+				data["GetCode"], data["Code"] = v.get(x, "code")
+				data["SetCode"] = v.set(x, code, "code")
+			}
+		case shape.MessageKey:
+			if v, ok := newOptionalValue(t, "string", op.Optional); ok {
+				data["GetMessage"], data["Message"] = v.get(x, "message")
+				data["SetMessage"] = v.set(x, "err.Error()", "message")
+			}
+		}
+	}
+	if fe := newFieldErrors(rs, op); fe != nil {
+		x := "e." + varfmt.PublicVarName(normalize(fe.Property.Name))
+		t := fe.Property.GoType(op)
+		items := x
+		if op.Optional == OptionalGeneric && strings.HasPrefix(t, "Optional[") {
+			t = strings.TrimSuffix(strings.TrimPrefix(t, "Optional["), "]")
+			items = x + ".Value"
+		}
+		makeFieldErrors := func(n string) []string {
+			if items != x {
+				return []string{fmt.Sprintf("%s = NewOptional(make(%s, %s))", x, t, n)}
+			}
+			return []string{fmt.Sprintf("%s = make(%s, %s)", x, t, n)}
+		}
+		data["FieldErrors"] = true
+		data["MakeValidationErrors"] = makeFieldErrors("len(v)")
+		data["SetValidationErrors"] = append(
+			fe.Name.set(items+"[i].Name", "fe.Field()", "name"),
+			fe.Message.set(items+"[i].Message", `fmt.Sprintf("%s failed on %s", fe.Field(), tag)`, "message")...)
+		data["MakeFieldError"] = makeFieldErrors("1")
+		data["SetFieldError"] = append(
+			fe.Name.set(items+"[0].Name", "v.Field()", "name"),
+			fe.Message.set(items+"[0].Message", "err.Error()", "message")...)
+	}
+
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Funcs(template.FuncMap{
+		// lines joins statements indented by depth tabs
+		"lines": func(depth int, stmts []string) string {
+			return strings.Join(stmts, "\n"+strings.Repeat("\t", depth))
+		},
+	}).Parse(`
+// Error implements error
+func (e *{{ .Name }}) Error() string {
+	{{- if .GetCode }}
+	{{ lines 1 .GetCode }}
+	{{- end }}
+	{{- if .GetMessage }}
+	{{ lines 1 .GetMessage }}
+	{{- end }}
+	{{- if and .Code .Message }}
+	return fmt.Sprintf("%v: %s", {{ .Code }}, {{ .Message }})
+	{{- else if .Message }}
+	return {{ .Message }}
+	{{- else if .Code }}
+	return fmt.Sprint({{ .Code }})
+	{{- else }}
+	b, _ := json.Marshal(e)
+	return string(b)
+	{{- end }}
+}
+
+// New{{ .Name }}FromValidation returns {{ .Name }} from validation failure
+func New{{ .Name }}FromValidation(err error) *{{ .Name }} {
+	e := &{{ .Name }}{}
+	{{- if .SetCode }}
+	{{ lines 1 .SetCode }}
+	{{- end }}
+	{{- if .SetMessage }}
+	{{ lines 1 .SetMessage }}
+	{{- end }}
+	{{- if .FieldErrors }}
+	switch v := err.(type) {
+	case validator.ValidationErrors:
+		{{ lines 2 .MakeValidationErrors }}
+		for i, fe := range v {
+			tag := fe.Tag()
+			if fe.Param() != "" {
+				tag += "=" + fe.Param()
+			}
+			{{ lines 3 .SetValidationErrors }}
+		}
+	case interface{ Field() string }:
+		// failure of single field, e.g. by request validation middleware
+		{{ lines 2 .MakeFieldError }}
+		{{ lines 2 .SetFieldError }}
+	}
+	{{- end }}
+	return e
+}
+`)
+	var src bytes.Buffer
+	tmpl.Execute(&src, data)
+	return src.Bytes()
+}
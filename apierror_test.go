@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func testErrorResource() *Resource {
+	str := []schema.PrimitiveType{schema.StringType}
+	return &Resource{
+		Name: "error",
+		Properties: []*Property{
+			&Property{
+				Name:     "code",
+				PropType: PropTypeScalar,
+				Types:    str,
+				Required: true,
+				Enum:     &Enum{Name: "error_code", Types: str, Values: []interface{}{"invalid_params", "unauthorized"}},
+			},
+			&Property{Name: "detail", PropType: PropTypeScalar, Types: str, Required: true},
+			&Property{
+				Name:     "errorFields",
+				PropType: PropTypeArray,
+				Types:    []schema.PrimitiveType{schema.ArrayType},
+				InlineProperties: []*Property{
+					&Property{Name: "message", PropType: PropTypeScalar, Types: str, Required: true},
+					&Property{Name: "name", PropType: PropTypeScalar, Types: str, Required: true},
+				},
+			},
+		},
+	}
+}
+
+func TestErrorResource(t *testing.T) {
+	task := schema.New()
+	apiErr := schema.New()
+	apiErr.Extras = map[string]interface{}{"x-go-error": true}
+	resources := map[string]Resource{
+		"task":      {Name: "task", Schema: task},
+		"api_error": {Name: "api_error", Schema: apiErr},
+	}
+	rs, err := errorResource(resources, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs == nil || rs.Name != "api_error" {
+		t.Errorf("want api_error got %v", rs)
+	}
+	if rs, err := errorResource(resources, "task"); err != nil || rs.Name != "task" {
+		t.Errorf("want task got %v, %v", rs, err)
+	}
+	if _, err := errorResource(resources, "error"); err == nil {
+		t.Error("want error for missing resource")
+	}
+	delete(resources, "api_error")
+	if rs, err := errorResource(resources, ""); err != nil || rs != nil {
+		t.Errorf("want nil got %v, %v", rs, err)
+	}
+}
+
+func TestNewErrorShape(t *testing.T) {
+	str := []schema.PrimitiveType{schema.StringType}
+	errRes := &Resource{
+		Name: "error",
+		Properties: []*Property{
+			&Property{
+				Name:     "code",
+				PropType: PropTypeScalar,
+				Types:    str,
+				Enum:     &Enum{Name: "error_code", Types: str, Values: []interface{}{"unauthorized", "invalid_params"}},
+			},
+			&Property{Name: "detail", PropType: PropTypeScalar, Types: str},
+		},
+	}
+	cases := []struct {
+		Resource *Resource
+		Expected ErrorShape
+	}{
+		{Resource: errRes, Expected: ErrorShape{CodeKey: "code", Code: "invalid_params", MessageKey: "detail"}},
+		{Resource: nil, Expected: ErrorShape{MessageKey: "message"}},
+	}
+	for _, c := range cases {
+		if s := NewErrorShape(c.Resource); s != c.Expected {
+			t.Errorf("want %+v got %+v", c.Expected, s)
+		}
+	}
+}
+
+func TestErrorFuncs(t *testing.T) {
+	// formatted as fragment like struct command, so that indentation is kept
+	ss, err := format.Source(ErrorFuncs(testErrorResource(), FormatOption{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(ss)
+	for _, expected := range []string{
+		"\n// Error implements error\nfunc (e *Error) Error() string {",
+		`return fmt.Sprintf("%v: %s", e.Code, e.Detail)`,
+		"\nfunc NewErrorFromValidation(err error) *Error {\n\te := &Error{}\n",
+		"e.Code = ErrorCodeInvalidParams",
+		"e.Detail = err.Error()",
+		"e.ErrorFields[i].Name = fe.Field()",
+		`e.ErrorFields[i].Message = fmt.Sprintf("%s failed on %s", fe.Field(), tag)`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("%s not found in %s", expected, s)
+		}
+	}
+}
+
+func TestErrorFuncsOptional(t *testing.T) {
+	cases := []struct {
+		Optional Optional
+		Expected []string
+	}{
+		{
+			Optional: OptionalPointer,
+			Expected: []string{
+				"var code ErrorCode\n\tif e.Code != nil {\n\t\tcode = *e.Code\n\t}",
+				`return fmt.Sprintf("%v: %s", code, message)`,
+				"code := ErrorCodeInvalidParams\n\te.Code = &code",
+				"name := fe.Field()\n\t\t\te.ErrorFields[i].Name = &name",
+			},
+		},
+		{
+			Optional: OptionalNull,
+			Expected: []string{
+				`return fmt.Sprintf("%v: %s", code, e.Detail.String)`,
+				"e.Detail = null.StringFrom(err.Error())",
+				"e.ErrorFields[0].Name = null.StringFrom(v.Field())",
+			},
+		},
+		{
+			Optional: OptionalSQLNull,
+			Expected: []string{
+				`return fmt.Sprintf("%v: %s", code, e.Detail.String)`,
+				"e.Detail = NullString{NullString: sql.NullString{String: err.Error(), Valid: true}}",
+			},
+		},
+		{
+			Optional: OptionalGeneric,
+			Expected: []string{
+				`return fmt.Sprintf("%v: %s", e.Code.Value, e.Detail.Value)`,
+				"e.Code = NewOptional(ErrorCodeInvalidParams)",
+				"e.ErrorFields = NewOptional(make([]struct {",
+				"e.ErrorFields.Value[i].Name = NewOptional(fe.Field())",
+			},
+		},
+	}
+	for _, c := range cases {
+		rs := testErrorResource()
+		for _, p := range rs.Properties {
+			p.Required = false
+			for _, ip := range p.InlineProperties {
+				ip.Required = false
+			}
+		}
+		op := FormatOption{Optional: c.Optional}
+		funcs := ErrorFuncs(rs, op)
+		ss, err := format.Source(funcs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := string(ss)
+		for _, expected := range c.Expected {
+			if !strings.Contains(s, expected) {
+				t.Errorf("%s: %s not found in %s", c.Optional, expected, s)
+			}
+		}
+		src := []byte("package main\n")
+		src = append(src, rs.Properties[0].Enum.Type()...)
+		src = append(src, rs.Struct(op)...)
+		src = append(src, funcs...)
+		switch c.Optional {
+		case OptionalGeneric:
+			src = append(src, optionalType...)
+		case OptionalSQLNull:
+			src = append(src, sqlNullWrappers(src)...)
+		}
+		if _, err := format.Source(src); err != nil {
+			t.Fatalf("%s: %s", err, src)
+		}
+		imports := []string{`"database/sql"`, `"encoding/json"`, `"fmt"`, `"gopkg.in/guregu/null.v3"`,
+			`validator "gopkg.in/go-playground/validator.v9"`}
+		typeCheck(t, imports, src)
+	}
+}
+
+// validatorStub declares methods of FieldError of validator.v9 used by generated code,
+// which doesn't have Error unlike v10
+const validatorStub = `package validator
+
+type FieldError interface {
+	Tag() string
+	ActualTag() string
+	Namespace() string
+	StructNamespace() string
+	Field() string
+	StructField() string
+	Value() interface{}
+	Param() string
+}
+
+type ValidationErrors []FieldError
+
+func (ve ValidationErrors) Error() string { return "" }
+`
+
+// jsvalStub declares JSVal of go-jsval used by generated middleware
+const jsvalStub = `package jsval
+
+type JSVal struct{}
+
+func (v *JSVal) Validate(x interface{}) error { return nil }
+`
+
+// nullStub declares types of guregu/null
+const nullStub = `package null
+
+import (
+	"database/sql"
+	"time"
+)
+
+type String struct {
+	sql.NullString
+}
+
+type Int struct {
+	sql.NullInt64
+}
+
+type Float struct {
+	sql.NullFloat64
+}
+
+type Bool struct {
+	sql.NullBool
+}
+
+type Time struct {
+	Time  time.Time
+	Valid bool
+}
+
+func StringFrom(s string) String { return String{} }
+`
+
+// schemaStub declares Encoder of gorilla/schema used by generated client
+const schemaStub = `package schema
+
+import "reflect"
+
+type Encoder struct{}
+
+func NewEncoder() *Encoder { return &Encoder{} }
+
+func (e *Encoder) Encode(src interface{}, dst map[string][]string) error { return nil }
+
+func (e *Encoder) RegisterEncoder(value interface{}, encoder func(reflect.Value) string) {}
+`
+
+// importStubs sources of packages used by generated code, which are not installed
+var importStubs = map[string]string{
+	"gopkg.in/go-playground/validator.v9": validatorStub,
+	"github.com/lestrrat-go/go-jsval":     jsvalStub,
+	"gopkg.in/guregu/null.v3":             nullStub,
+	"github.com/gorilla/schema":           schemaStub,
+}
+
+// stubImporter imports packages from importStubs, others from installed packages
+type stubImporter struct {
+	fset *token.FileSet
+	std  types.Importer
+}
+
+func (i stubImporter) Import(path string) (*types.Package, error) {
+	stub, ok := importStubs[path]
+	if !ok {
+		return i.std.Import(path)
+	}
+	f, err := parser.ParseFile(i.fset, path+".go", stub, 0)
+	if err != nil {
+		return nil, err
+	}
+	conf := types.Config{Importer: i}
+	return conf.Check(path, i.fset, []*ast.File{f}, nil)
+}
+
+// typeCheck type checks files of package main. files without import declaration
+// import imports, and unused ones are ignored like goimports
+func typeCheck(t *testing.T, imports []string, files ...[]byte) {
+	fset := token.NewFileSet()
+	var fs []*ast.File
+	for i, src := range files {
+		if !bytes.Contains(src, []byte("\nimport (")) {
+			decl := "\nimport (\n" + strings.Join(imports, "\n") + "\n)\n"
+			n := bytes.IndexByte(src, '\n')
+			src = append(append(append([]byte{}, src[:n+1]...), decl...), src[n+1:]...)
+		}
+		f, err := parser.ParseFile(fset, fmt.Sprintf("file%d.go", i), src, 0)
+		if err != nil {
+			t.Fatalf("%s: %s", err, src)
+		}
+		fs = append(fs, f)
+	}
+	var errs []string
+	conf := types.Config{
+		Importer: stubImporter{fset: fset, std: importer.Default()},
+		Error: func(err error) {
+			if msg := err.Error(); !strings.Contains(msg, "imported") || !strings.HasSuffix(msg, "not used") {
+				errs = append(errs, err.Error())
+			}
+		},
+	}
+	conf.Check("main", fset, fs, nil)
+	if len(errs) != 0 {
+		t.Errorf("%s", strings.Join(errs, "\n"))
+	}
+}
+
+func TestErrorFuncsTypeCheck(t *testing.T) {
+	rs := testErrorResource()
+	src := []byte(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	validator "gopkg.in/go-playground/validator.v9"
+)
+`)
+	src = append(src, rs.Properties[0].Enum.Type()...)
+	src = append(src, rs.Struct(FormatOption{})...)
+	src = append(src, ErrorFuncs(rs, FormatOption{})...)
+	if _, err := format.Source(src); err != nil {
+		t.Fatalf("%s: %s", err, src)
+	}
+	typeCheck(t, nil, src)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// clientBase is the client type and its helpers shared by all methods
+const clientBase = `
+// Client API client
+type Client struct {
+	URL        *url.URL
+	HTTPClient *http.Client
+}
+
+// NewClient creates API client
+func NewClient(baseURL string, httpClient *http.Client) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{URL: u, HTTPClient: httpClient}, nil
+}
+
+// ResponseError non-2xx response from API. Err holds error decoded from body
+// if error resource is defined
+type ResponseError struct {
+	StatusCode int
+	Body       []byte
+	Err        error
+}
+
+func (e *ResponseError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Body)
+}
+
+// Unwrap returns error decoded from body
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+var formEncoder = schema.NewEncoder()
+
+func init() {
+	formEncoder.RegisterEncoder(time.Time{}, func(v reflect.Value) string {
+		return v.Interface().(time.Time).Format(time.RFC3339)
+	})
+}
+
+func (c *Client) endpoint(path string) *url.URL {
+	u := *c.URL
+	u.Path = strings.TrimRight(u.Path, "/") + path
+	return &u
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.endpoint(path).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	return req.WithContext(ctx), nil
+}
+
+func (c *Client) newQueryRequest(ctx context.Context, method, path string, params interface{}) (*http.Request, error) {
+	q := url.Values{}
+	if err := formEncoder.Encode(params, q); err != nil {
+		return nil, err
+	}
+	u := c.endpoint(path)
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	return req.WithContext(ctx), nil
+}
+
+func (c *Client) newFormRequest(ctx context.Context, method, path string, params interface{}) (*http.Request, error) {
+	f := url.Values{}
+	if err := formEncoder.Encode(params, f); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, c.endpoint(path).String(), strings.NewReader(f.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req.WithContext(ctx), nil
+}
+
+func (c *Client) newJSONRequest(ctx context.Context, method, path string, params interface{}) (*http.Request, error) {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, c.endpoint(path).String(), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	return req.WithContext(ctx), nil
+}
+
+func (c *Client) do(req *http.Request, v interface{}) error {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return &ResponseError{StatusCode: resp.StatusCode, Body: b, Err: decodeError(b)}
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+`
+
+// clientImports imports used by generated client file
+var clientImports = []string{
+	"bytes",
+	"context",
+	"encoding/json",
+	"fmt",
+	"io/ioutil",
+	"net/http",
+	"net/url",
+	"reflect",
+	"strings",
+	"time",
+	"",
+	"github.com/gorilla/schema",
+}
+
+// importDecl returns import declaration. empty string separates groups
+func importDecl(imports []string) []byte {
+	var src bytes.Buffer
+	fmt.Fprint(&src, "import (\n")
+	for _, imp := range imports {
+		if imp == "" {
+			fmt.Fprint(&src, "\n")
+			continue
+		}
+		fmt.Fprintf(&src, "\"%s\"\n", imp)
+	}
+	fmt.Fprint(&src, ")\n")
+	return src.Bytes()
+}
+
+// Client returns go client type representation. non-2xx responses are decoded
+// into errType if it is not empty, which implements error by struct command
+// generated with the same error resource
+func Client(errType string) []byte {
+	var src bytes.Buffer
+	src.Write(importDecl(clientImports))
+	src.WriteString(clientBase)
+	src.Write(decodeErrorFunc(errType))
+	return src.Bytes()
+}
+
+// decodeErrorFunc returns go function decoding error response body
+func decodeErrorFunc(errType string) []byte {
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Parse(`
+func decodeError(b []byte) error {
+	{{- if . }}
+	var e {{ . }}
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil
+	}
+	return &e
+	{{- else }}
+	return nil
+	{{- end }}
+}
+`)
+	var src bytes.Buffer
+	tmpl.Execute(&src, errType)
+	return src.Bytes()
+}
+
+// MethodArgs returns go method arguments of action shared by client and server
+func (a *Action) MethodArgs(op FormatOption) string {
+	args := []string{"ctx context.Context"}
+	for _, p := range a.PathParams {
+		args = append(args, p.VarName()+" "+p.GoType())
+	}
+	if a.Request != nil {
+		args = append(args, "params *"+a.RequestStructName(op))
+	}
+	return strings.Join(args, ", ")
+}
+
+// ClientMethod returns go client method representation of action
+func (a *Action) ClientMethod(op FormatOption) []byte {
+	var pathArgs []string
+	for _, p := range a.PathParams {
+		pathArgs = append(pathArgs, p.VarName())
+	}
+	path := fmt.Sprintf("%s(%s)", a.PathFuncName(op), strings.Join(pathArgs, ", "))
+
+	var newReq string
+	switch {
+	case a.Request == nil:
+		newReq = fmt.Sprintf("c.newRequest(ctx, %q, path)", a.Method)
+	case a.Method == "GET":
+		newReq = fmt.Sprintf("c.newQueryRequest(ctx, %q, path, params)", a.Method)
+	case a.Encoding == "application/x-www-form-urlencoded":
+		newReq = fmt.Sprintf("c.newFormRequest(ctx, %q, path, params)", a.Method)
+	default:
+		newReq = fmt.Sprintf("c.newJSONRequest(ctx, %q, path, params)", a.Method)
+	}
+
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Parse(`
+	// {{ .Name }} {{ .Method }}: {{ .Href }}
+	func (c *Client) {{ .Name }}({{ .Args }}) (*{{ .Response }}, error) {
+		{{- if .Request }}
+		if params == nil {
+			params = &{{ .Request }}{}
+		}
+		{{- end }}
+		path := {{ .Path }}
+		req, err := {{ .NewRequest }}
+		if err != nil {
+			return nil, err
+		}
+		var res {{ .Response }}
+		if err := c.do(req, &res); err != nil {
+			return nil, err
+		}
+		return &res, nil
+	}
+	`)
+	var request string
+	if a.Request != nil {
+		request = a.RequestStructName(op)
+	}
+	var src bytes.Buffer
+	tmpl.Execute(&src, map[string]string{
+		"Name":       a.Name(op),
+		"Method":     a.Method,
+		"Href":       a.Href,
+		"Args":       a.MethodArgs(op),
+		"Request":    request,
+		"Response":   a.ResponseStructName(op),
+		"Path":       path,
+		"NewRequest": newReq,
+	})
+	return src.Bytes()
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestClientMethod(t *testing.T) {
+	task := &Resource{Name: "task", IsPrimary: true}
+	cases := []struct {
+		action   Action
+		expected string
+	}{
+		{
+			action: Action{
+				Encoding: "application/json",
+				Href:     "/tasks/{(#/definitions/task/definitions/identity)}",
+				Method:   "GET",
+				Rel:      "self",
+				PathParams: []PathParam{
+					{Name: "id", Reference: "#/definitions/task/definitions/identity"},
+				},
+				Response: task,
+			},
+			expected: `// TaskSelf GET: /tasks/{(#/definitions/task/definitions/identity)}
+func (c *Client) TaskSelf(ctx context.Context, id string) (*TaskSelfResponse, error) {
+	path := TaskSelfPath(id)
+	req, err := c.newRequest(ctx, "GET", path)
+	if err != nil {
+		return nil, err
+	}
+	var res TaskSelfResponse
+	if err := c.do(req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+`,
+		},
+		{
+			action: Action{
+				Encoding: "application/json",
+				Href:     "/tasks",
+				Method:   "POST",
+				Rel:      "create",
+				Request:  &Resource{Name: "task"},
+				Response: task,
+			},
+			expected: `// TaskCreate POST: /tasks
+func (c *Client) TaskCreate(ctx context.Context, params *TaskCreateRequest) (*TaskCreateResponse, error) {
+	if params == nil {
+		params = &TaskCreateRequest{}
+	}
+	path := TaskCreatePath()
+	req, err := c.newJSONRequest(ctx, "POST", path, params)
+	if err != nil {
+		return nil, err
+	}
+	var res TaskCreateResponse
+	if err := c.do(req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+`,
+		},
+		{
+			action: Action{
+				Encoding: "application/json",
+				Href:     "/tasks",
+				Method:   "GET",
+				Rel:      "instances",
+				Request:  &Resource{Name: "task"},
+				Response: task,
+			},
+			expected: `	req, err := c.newQueryRequest(ctx, "GET", path, params)`,
+		},
+		{
+			action: Action{
+				Encoding: "application/x-www-form-urlencoded",
+				Href:     "/tasks",
+				Method:   "POST",
+				Rel:      "import",
+				Request:  &Resource{Name: "task"},
+				Response: task,
+			},
+			expected: `	req, err := c.newFormRequest(ctx, "POST", path, params)`,
+		},
+	}
+	for _, c := range cases {
+		ss, err := format.Source(append([]byte("package main\n"), c.action.ClientMethod(FormatOption{})...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(ss), c.expected) {
+			t.Errorf("want %s got %s", c.expected, ss)
+		}
+	}
+}
+
+func TestClientDecodeError(t *testing.T) {
+	cases := []struct {
+		errType  string
+		expected string
+	}{
+		{
+			errType: "Error",
+			expected: `func decodeError(b []byte) error {
+	var e Error
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil
+	}
+	return &e
+}
+`,
+		},
+		{
+			errType: "",
+			expected: `func decodeError(b []byte) error {
+	return nil
+}
+`,
+		},
+	}
+	for _, c := range cases {
+		src := Client(c.errType)
+		ss, err := format.Source(append([]byte("package main\n"), src...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(ss), c.expected) {
+			t.Errorf("want %s got %s", c.expected, ss)
+		}
+		expected := "return &ResponseError{StatusCode: resp.StatusCode, Body: b, Err: decodeError(b)}"
+		if !strings.Contains(string(ss), expected) {
+			t.Errorf("want %s got %s", expected, ss)
+		}
+	}
+}
+
+func TestClientTypeCheck(t *testing.T) {
+	generate := func(f func(fp *os.File, out *bytes.Buffer) error) []byte {
+		fp, err := os.Open("./example/doc/schema/schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fp.Close()
+		var out bytes.Buffer
+		if err := f(fp, &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.Bytes()
+	}
+	pkg := "main"
+	cl := generate(func(fp *os.File, out *bytes.Buffer) error {
+		return generateClientFile(&pkg, fp, out, false, "error")
+	})
+	imports := []string{`"database/sql"`, `"encoding/json"`, `"fmt"`, `"net/url"`, `"regexp"`, `"strconv"`, `"time"`,
+		`"gopkg.in/guregu/null.v3"`, `validator "gopkg.in/go-playground/validator.v9"`}
+	for _, o := range []Optional{OptionalNone, OptionalPointer, OptionalNull, OptionalSQLNull, OptionalGeneric} {
+		// decoded error is returned as error implemented by struct command
+		st := generate(func(fp *os.File, out *bytes.Buffer) error {
+			return generateStructFile(&pkg, fp, out, true, false, false, o, nil, "error")
+		})
+		typeCheck(t, imports, st, cl)
+	}
+}
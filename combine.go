@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// rootSchema $schema of combined schema, same as `prmd combine`
+const rootSchema = "http://interagent.github.io/interagent-hyper-schema"
+
+var metaFiles = []string{"meta.yml", "meta.yaml", "meta.json"}
+
+// openSchema opens JSON Schema file, or combines prmd schemata if path is a directory.
+// output file is excluded from schemata
+func openSchema(path, output string) (io.Reader, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return os.Open(path)
+	}
+	root, err := CombineSchemata(path, output)
+	if err != nil {
+		return nil, err
+	}
+	b, err := MarshalSchema(root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode combined schema")
+	}
+	return bytes.NewReader(b), nil
+}
+
+// isSchemaFile returns true if path has JSON or YAML extension
+func isSchemaFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".json", ".yml", ".yaml":
+		return true
+	default:
+		return false
+	}
+}
+
+// readSchemaFile reads JSON or YAML schema file as object keeping key order
+func readSchemaFile(path string) (yaml.MapSlice, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is parsed as YAML to keep key order
+	var v yaml.MapSlice
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return convertYAML(v).(yaml.MapSlice), nil
+}
+
+// convertYAML converts keys of maps decoded by yaml to string
+func convertYAML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case yaml.MapSlice:
+		m := make(yaml.MapSlice, len(t))
+		for i, item := range t {
+			m[i] = yaml.MapItem{Key: fmt.Sprint(item.Key), Value: convertYAML(item.Value)}
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, e := range t {
+			s[i] = convertYAML(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// lookup returns value of key in object
+func lookup(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// put sets value of key in object. key is appended if not exist
+func put(m yaml.MapSlice, key string, v interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = v
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: v})
+}
+
+// remove deletes key from object
+func remove(m yaml.MapSlice, key string) yaml.MapSlice {
+	var res yaml.MapSlice
+	for _, item := range m {
+		if item.Key != key {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// MarshalSchema returns indented JSON of schema keeping key order of objects
+func MarshalSchema(v interface{}) ([]byte, error) {
+	var src bytes.Buffer
+	if err := writeJSON(&src, v); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, src.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteString("\n")
+	return out.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case yaml.MapSlice:
+		buf.WriteString("{")
+		for i, item := range t {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeJSON(buf, fmt.Sprint(item.Key)); err != nil {
+				return err
+			}
+			buf.WriteString(":")
+			if err := writeJSON(buf, item.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case []interface{}:
+		buf.WriteString("[")
+		for i, e := range t {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeJSON(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	default:
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		// Encode appends newline
+		buf.Truncate(buf.Len() - 1)
+	}
+	return nil
+}
+
+// schemataFiles returns paths of schemata in dir sorted. schemata/ sub directory is
+// used if exists, otherwise files in dir except meta. files same as exclude, e.g.
+// output file of combined schema, are skipped
+func schemataFiles(dir string, exclude []string) ([]string, error) {
+	if fi, err := os.Stat(filepath.Join(dir, "schemata")); err == nil && fi.IsDir() {
+		dir = filepath.Join(dir, "schemata")
+	}
+	var excluded []os.FileInfo
+	for _, path := range exclude {
+		if path == "" {
+			continue
+		}
+		if fi, err := os.Stat(path); err == nil {
+			excluded = append(excluded, fi)
+		}
+	}
+	var paths []string
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || !isSchemaFile(path) {
+			return nil
+		}
+		for _, m := range metaFiles {
+			if path == filepath.Join(dir, m) {
+				return nil
+			}
+		}
+		for _, ex := range excluded {
+			if os.SameFile(fi, ex) {
+				return nil
+			}
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// CombineSchemata combines prmd schemata in dir and its meta.yml into root schema,
+// the same way as `prmd combine --meta meta.yml schemata/`. schemata defining
+// the same definition are reported as error. files in exclude are not combined
+func CombineSchemata(dir string, exclude ...string) (yaml.MapSlice, error) {
+	var meta yaml.MapSlice
+	for _, m := range metaFiles {
+		path := filepath.Join(dir, m)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		v, err := readSchemaFile(path)
+		if err != nil {
+			return nil, err
+		}
+		meta = v
+		break
+	}
+	if meta == nil {
+		return nil, errors.Errorf("meta.yml not found in %s", dir)
+	}
+	paths, err := schemataFiles(dir, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs yaml.MapSlice
+	ids := make(map[string]string)
+	files := make(map[string]string)
+	for _, path := range paths {
+		sc, err := readSchemaFile(path)
+		if err != nil {
+			return nil, err
+		}
+		v, _ := lookup(sc, "id")
+		id, ok := v.(string)
+		if !ok || id == "" {
+			return nil, errors.Errorf("id is not defined in %s", path)
+		}
+		name := id[strings.LastIndex(id, "/")+1:]
+		if f, ok := files[name]; ok {
+			return nil, errors.Errorf("duplicate definition %s in %s and %s", name, f, path)
+		}
+		files[name] = path
+		ids[strings.TrimPrefix(id, "/")] = name
+		defs = append(defs, yaml.MapItem{Key: name, Value: remove(sc, "id")})
+	}
+
+	var props yaml.MapSlice
+	for i, def := range defs {
+		name := def.Key.(string)
+		defs[i].Value = rewriteRefs(def.Value, ids)
+		props = append(props, yaml.MapItem{Key: name, Value: yaml.MapSlice{{Key: "$ref", Value: "#/definitions/" + name}}})
+	}
+	root := yaml.MapSlice{
+		{Key: "$schema", Value: rootSchema},
+		{Key: "type", Value: []interface{}{"object"}},
+		{Key: "definitions", Value: defs},
+		{Key: "properties", Value: props},
+	}
+	for _, item := range meta {
+		if item.Key == "definitions" || item.Key == "properties" {
+			return nil, errors.Errorf("%s must not be defined in meta", item.Key)
+		}
+		root = put(root, item.Key.(string), item.Value)
+	}
+	return root, nil
+}
+
+// combinedRef returns reference to schemata, e.g. /schemata/task#/definitions/id,
+// rewritten to reference in combined schema, e.g. #/definitions/task/definitions/id
+func combinedRef(ref string, ids map[string]string) string {
+	id, frag := ref, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		id, frag = ref[:i], ref[i+1:]
+	}
+	name, ok := ids[strings.TrimPrefix(id, "/")]
+	if !ok {
+		return ref
+	}
+	return "#/definitions/" + name + frag
+}
+
+// rewriteRefs rewrites $ref and href template references to schemata
+func rewriteRefs(v interface{}, ids map[string]string) interface{} {
+	switch t := v.(type) {
+	case yaml.MapSlice:
+		for i, item := range t {
+			s, ok := item.Value.(string)
+			switch {
+			case item.Key == "$ref" && ok:
+				t[i].Value = combinedRef(s, ids)
+			case item.Key == "href" && ok:
+				t[i].Value = hrefParamRe.ReplaceAllStringFunc(s, func(m string) string {
+					ref, err := url.QueryUnescape(m[2 : len(m)-2])
+					if err != nil {
+						return m
+					}
+					return "{(" + url.QueryEscape(combinedRef(ref, ids)) + ")}"
+				})
+			default:
+				t[i].Value = rewriteRefs(item.Value, ids)
+			}
+		}
+		return t
+	case []interface{}:
+		for i, e := range t {
+			t[i] = rewriteRefs(e, ids)
+		}
+		return t
+	default:
+		return v
+	}
+}
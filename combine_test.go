@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestCombineSchemata(t *testing.T) {
+	root, err := CombineSchemata("./example/doc/schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MarshalSchema(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// example schema.json is combined by prmd
+	expected, err := ioutil.ReadFile("./example/doc/schema/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, expected) {
+		t.Errorf("combined schema differs from prmd combine:\n%s", b)
+	}
+}
+
+func TestCombineSchemataDuplicate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prmdg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"meta.yml":         "id: tasky\n",
+		"schemata/a.yml":   "id: schemata/task\n",
+		"schemata/b.json":  `{"id": "schemata/task"}`,
+		"schemata/c.yaml":  "id: schemata/user\n",
+		"schemata/doc.txt": "not a schema",
+	}
+	if err := os.Mkdir(filepath.Join(dir, "schemata"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = CombineSchemata(dir)
+	if err == nil || !strings.Contains(err.Error(), "duplicate definition task") {
+		t.Errorf("want duplicate definition error got %v", err)
+	}
+}
+
+func TestGenerateCombinedFileExcludesOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prmdg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"meta.yml": "id: tasky\n",
+		"task.yml": "id: schemata/task\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// output file is created before combining like -o, without schemata/ sub directory
+	output := filepath.Join(dir, "schema.json")
+	out, err := os.Create(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if err := generateCombinedFile(dir, out, output); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"task": {`) {
+		t.Errorf("task not combined: %s", b)
+	}
+
+	// combining again reads the output file written above
+	paths, err := schemataFiles(dir, []string{output})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != filepath.Join(dir, "task.yml") {
+		t.Errorf("want task.yml got %v", paths)
+	}
+}
+
+func TestCombinedRef(t *testing.T) {
+	ids := map[string]string{"schemata/task": "task"}
+	cases := []struct {
+		ref      string
+		expected string
+	}{
+		{ref: "/schemata/task", expected: "#/definitions/task"},
+		{ref: "/schemata/task#/definitions/id", expected: "#/definitions/task/definitions/id"},
+		{ref: "schemata/task#/definitions/id", expected: "#/definitions/task/definitions/id"},
+		{ref: "#/definitions/task", expected: "#/definitions/task"},
+		{ref: "/schemata/user", expected: "/schemata/user"},
+	}
+	for _, c := range cases {
+		if got := combinedRef(c.ref, ids); got != c.expected {
+			t.Errorf("want %s got %s", c.expected, got)
+		}
+	}
+}
+
+func TestRewriteRefsHref(t *testing.T) {
+	ids := map[string]string{"schemata/task": "task"}
+	v := rewriteRefs(yaml.MapSlice{
+		{Key: "href", Value: "/tasks/{(%2Fschemata%2Ftask%23%2Fdefinitions%2Fidentity)}"},
+	}, ids).(yaml.MapSlice)
+	expected := "/tasks/{(%23%2Fdefinitions%2Ftask%2Fdefinitions%2Fidentity)}"
+	if href, _ := lookup(v, "href"); href != expected {
+		t.Errorf("want %s got %s", expected, href)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/achiku/varfmt"
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+// baseLiteral returns go literal of default value v as base type t
+func (pr *Property) baseLiteral(t string, v interface{}) (string, bool) {
+	if pr.Enum != nil && t == pr.Enum.TypeName() {
+		for _, ev := range pr.Enum.Values {
+			if fmt.Sprint(ev) == fmt.Sprint(v) {
+				return pr.Enum.ConstName(v), true
+			}
+		}
+		return "", false
+	}
+	switch t {
+	case "string":
+		s, ok := v.(string)
+		return strconv.Quote(s), ok
+	case "int64":
+		f, ok := v.(float64)
+		return strconv.FormatInt(int64(f), 10), ok && f == float64(int64(f))
+	case "float64":
+		f, ok := v.(float64)
+		return strconv.FormatFloat(f, 'g', -1, 64), ok
+	case "bool":
+		b, ok := v.(bool)
+		return strconv.FormatBool(b), ok
+	default:
+		return "", false
+	}
+}
+
+// DefaultLiteral returns go literal of default value. returns false if property
+// doesn't have default, or default of its type is not supported
+func (pr *Property) DefaultLiteral(op FormatOption) (string, bool) {
+	if pr.Default == nil || pr.GoTypeOverride != nil {
+		return "", false
+	}
+	t := pr.GoType(op)
+	var (
+		base string
+		lit  string
+	)
+	switch pr.PropType {
+	case PropTypeScalar:
+		base = pr.ScalarType(FormatOption{TypeMap: op.TypeMap})
+		l, ok := pr.baseLiteral(base, pr.Default)
+		if !ok {
+			return "", false
+		}
+		lit = l
+	case PropTypeArray:
+		if pr.Union != nil || len(pr.InlineProperties) != 0 || pr.SecondTypes.Contains(schema.ObjectType) {
+			return "", false
+		}
+		elem := pr.ScalarType(op)
+		values, ok := pr.Default.([]interface{})
+		if !ok {
+			return "", false
+		}
+		var lits []string
+		for _, v := range values {
+			l, ok := pr.baseLiteral(elem, v)
+			if !ok {
+				return "", false
+			}
+			lits = append(lits, l)
+		}
+		base = "[]" + elem
+		lit = base + "{" + strings.Join(lits, ", ") + "}"
+	default:
+		return "", false
+	}
+
+	switch {
+	case t == base:
+		return lit, true
+	case t == "*"+base:
+		return fmt.Sprintf("func(v %s) *%s { return &v }(%s)", base, base, lit), true
+	case t == "Optional["+base+"]":
+		return fmt.Sprintf("%s{Value: %s, Valid: true}", t, lit), true
+	case strings.HasPrefix(t, "null."):
+		return fmt.Sprintf("%sFrom(%s)", t, lit), true
+	case sqlNullTypes[t] != "":
+		return fmt.Sprintf("%s{%s: sql.%s{%s: %s, Valid: true}}", t, t, t, sqlNullTypes[t], lit), true
+	default:
+		return "", false
+	}
+}
+
+// zeroCheck returns go condition checking if field of type t is zero value
+func zeroCheck(field, t string) string {
+	switch {
+	case strings.HasPrefix(t, "*") || strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map["):
+		return field + " == nil"
+	case strings.HasPrefix(t, "null.") || sqlNullTypes[t] != "" || strings.HasPrefix(t, "Optional["):
+		return "!" + field + ".Valid"
+	case t == "string":
+		return field + ` == ""`
+	case t == "bool":
+		return "!" + field
+	default:
+		return field + " == 0"
+	}
+}
+
+// defaultFuncs returns constructor populating default values, and ApplyDefaults
+// filling zero-valued optional fields. returns empty if no property has default
+func defaultFuncs(name string, props []*Property, op FormatOption) []byte {
+	type field struct {
+		Name    string
+		Literal string
+		Zero    string
+	}
+	var (
+		fields   []field
+		optional []field
+	)
+	for _, p := range props {
+		lit, ok := p.DefaultLiteral(op)
+		if !ok {
+			continue
+		}
+		f := field{Name: varfmt.PublicVarName(normalize(p.Name)), Literal: lit}
+		fields = append(fields, f)
+		if !p.Required {
+			t := p.GoType(op)
+			if p.Enum != nil && t == p.Enum.TypeName() {
+				t = p.Enum.BaseType()
+			}
+			f.Zero = zeroCheck("r."+f.Name, t)
+			optional = append(optional, f)
+		}
+	}
+	if len(fields) == 0 {
+		return []byte("")
+	}
+
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Parse(`
+// New{{ .Name }} returns {{ .Name }} with default values
+func New{{ .Name }}() *{{ .Name }} {
+	return &{{ .Name }}{
+		{{- range .Fields }}
+		{{ .Name }}: {{ .Literal }},
+		{{- end }}
+	}
+}
+
+// ApplyDefaults sets default values to zero-valued optional fields of {{ .Name }}
+func (r *{{ .Name }}) ApplyDefaults() {
+	{{- range .Optional }}
+	if {{ .Zero }} {
+		r.{{ .Name }} = {{ .Literal }}
+	}
+	{{- end }}
+}
+`)
+	var src bytes.Buffer
+	tmpl.Execute(&src, map[string]interface{}{
+		"Name":     name,
+		"Fields":   fields,
+		"Optional": optional,
+	})
+	return src.Bytes()
+}
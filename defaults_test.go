@@ -0,0 +1,89 @@
+package main
+
+import (
+	"go/format"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func TestDefaultLiteral(t *testing.T) {
+	str := []schema.PrimitiveType{schema.StringType}
+	integer := []schema.PrimitiveType{schema.IntegerType}
+	status := &Enum{Name: "task_status", Types: str, Values: []interface{}{"todo", "done"}}
+	cases := []struct {
+		Property Property
+		Optional Optional
+		Expected string
+	}{
+		{Property: Property{Types: str, Default: "a", Required: true}, Expected: `"a"`},
+		{Property: Property{Types: integer, Default: float64(3)}, Expected: "3"},
+		{Property: Property{Types: integer, Default: float64(3)}, Optional: OptionalPointer, Expected: "func(v int64) *int64 { return &v }(3)"},
+		{Property: Property{Types: integer, Default: float64(3)}, Optional: OptionalNull, Expected: "null.IntFrom(3)"},
+		{Property: Property{Types: integer, Default: float64(3)}, Optional: OptionalSQLNull, Expected: "NullInt64{NullInt64: sql.NullInt64{Int64: 3, Valid: true}}"},
+		{Property: Property{Types: integer, Default: float64(3)}, Optional: OptionalGeneric, Expected: "Optional[int64]{Value: 3, Valid: true}"},
+		{Property: Property{Types: str, Enum: status, Default: "todo"}, Expected: "TaskStatusTodo"},
+		{
+			Property: Property{
+				PropType:    PropTypeArray,
+				Types:       []schema.PrimitiveType{schema.ArrayType},
+				SecondTypes: str,
+				Default:     []interface{}{"a", "b"},
+			},
+			Expected: `[]string{"a", "b"}`,
+		},
+	}
+	for _, c := range cases {
+		l, ok := c.Property.DefaultLiteral(FormatOption{Optional: c.Optional})
+		if !ok || l != c.Expected {
+			t.Errorf("want %s got %s", c.Expected, l)
+		}
+	}
+	for _, p := range []Property{
+		{Types: str},
+		{Types: str, Default: float64(1)},
+		{Types: integer, Default: 1.5},
+		{Types: str, Format: "date-time", Default: "2017-01-01T00:00:00Z"},
+	} {
+		if l, ok := p.DefaultLiteral(FormatOption{}); ok {
+			t.Errorf("want no default got %s", l)
+		}
+	}
+}
+
+func TestDefaultFuncs(t *testing.T) {
+	str := []schema.PrimitiveType{schema.StringType}
+	props := []*Property{
+		&Property{Name: "title", Types: str, Required: true, Default: "untitled"},
+		&Property{Name: "note", Types: str, Default: "none"},
+		&Property{Name: "done", Types: []schema.PrimitiveType{schema.BooleanType}},
+	}
+	expected := `
+// NewTaskCreateRequest returns TaskCreateRequest with default values
+func NewTaskCreateRequest() *TaskCreateRequest {
+	return &TaskCreateRequest{
+		Title: "untitled",
+		Note:  null.StringFrom("none"),
+	}
+}
+
+// ApplyDefaults sets default values to zero-valued optional fields of TaskCreateRequest
+func (r *TaskCreateRequest) ApplyDefaults() {
+	if !r.Note.Valid {
+		r.Note = null.StringFrom("none")
+	}
+}
+`
+	// formatted as fragment like struct command, so that indentation is kept
+	src := defaultFuncs("TaskCreateRequest", props, FormatOption{Optional: OptionalNull})
+	ss, err := format.Source(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ss) != expected {
+		t.Errorf("want %s got %s", expected, ss)
+	}
+	if b := defaultFuncs("Task", props[2:], FormatOption{}); len(b) != 0 {
+		t.Errorf("want empty got %s", b)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/achiku/varfmt"
+	schema "github.com/lestrrat-go/jsschema"
+	"github.com/pkg/errors"
+)
+
+var nonIdentRe = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// Enum enum type. Nullable is set if null is one of enum values, which is not
+// included in Values
+type Enum struct {
+	Name     string
+	Types    schema.PrimitiveTypes
+	Values   []interface{}
+	Nullable bool
+}
+
+// NewEnum creates enum from schema. returns nil if schema is not enum of string or numbers,
+// and error if values are converted to the same constant name, e.g. in-progress and in_progress
+func NewEnum(name string, sc *schema.Schema) (*Enum, error) {
+	if !sc.Type.Contains(schema.StringType) &&
+		!sc.Type.Contains(schema.IntegerType) && !sc.Type.Contains(schema.NumberType) {
+		return nil, nil
+	}
+	e := &Enum{
+		Name:  name,
+		Types: sc.Type,
+	}
+	for _, v := range sc.Enum {
+		if v == nil {
+			e.Nullable = true
+			continue
+		}
+		e.Values = append(e.Values, v)
+	}
+	if len(e.Values) == 0 {
+		return nil, nil
+	}
+	consts := make(map[string]string)
+	for _, v := range e.Values {
+		c, l := e.ConstName(v), e.literal(v)
+		if other, ok := consts[c]; ok {
+			return nil, errors.Errorf("enum values %s and %s have the same constant name %s", other, l, c)
+		}
+		consts[c] = l
+	}
+	return e, nil
+}
+
+// refToEnumName returns enum name from reference,
+// e.g. #/definitions/task/definitions/status -> task_status
+func refToEnumName(ref string) string {
+	if !strings.HasPrefix(ref, "#/definitions/") {
+		return ""
+	}
+	n := strings.Replace(ref, "#/definitions/", "", 1)
+	return normalize(strings.Replace(n, "/definitions/", "_", -1))
+}
+
+// sortEnums returns enums sorted by type name
+func sortEnums(enums map[string]*Enum) []*Enum {
+	var names []string
+	for n := range enums {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var sorted []*Enum
+	for _, n := range names {
+		sorted = append(sorted, enums[n])
+	}
+	return sorted
+}
+
+// TypeName returns go type name of enum
+func (e *Enum) TypeName() string {
+	return varfmt.PublicVarName(e.Name)
+}
+
+// BaseType returns go underlying type of enum
+func (e *Enum) BaseType() string {
+	switch {
+	case e.Types.Contains(schema.StringType):
+		return "string"
+	case e.Types.Contains(schema.IntegerType):
+		return "int64"
+	default:
+		return "float64"
+	}
+}
+
+// literal returns go literal of enum value
+func (e *Enum) literal(v interface{}) string {
+	switch e.BaseType() {
+	case "string":
+		return strconv.Quote(fmt.Sprint(v))
+	case "int64":
+		if f, ok := v.(float64); ok {
+			return strconv.FormatInt(int64(f), 10)
+		}
+		return fmt.Sprint(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ConstName returns go constant name of enum value
+func (e *Enum) ConstName(v interface{}) string {
+	s := strings.Trim(nonIdentRe.ReplaceAllString(strings.Trim(e.literal(v), `"`), "_"), "_")
+	if s == "" {
+		s = "empty"
+	}
+	return e.TypeName() + varfmt.PublicVarName(s)
+}
+
+// Type returns go type representation of enum
+func (e *Enum) Type() []byte {
+	type value struct {
+		Const   string
+		Literal string
+	}
+	var (
+		values []value
+		consts []string
+	)
+	for _, v := range e.Values {
+		values = append(values, value{Const: e.ConstName(v), Literal: e.literal(v)})
+		consts = append(consts, e.ConstName(v))
+	}
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Parse(`
+// {{ .Name }} enum for {{ .Raw }}
+type {{ .Name }} {{ .Base }}
+
+// {{ .Name }} values
+const (
+	{{- range .Values }}
+	{{ .Const }} {{ $.Name }} = {{ .Literal }}
+	{{- end }}
+)
+
+// Valid returns true if value is one of {{ .Name }} values
+func (e {{ .Name }}) Valid() bool {
+	switch e {
+	case {{ .Consts }}:
+		return true
+	}
+	return false
+}
+
+// UnmarshalJSON rejects values not defined in {{ .Name }}
+func (e *{{ .Name }}) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var v {{ .Base }}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if !{{ .Name }}(v).Valid() {
+		return fmt.Errorf("invalid {{ .Name }}: %v", v)
+	}
+	*e = {{ .Name }}(v)
+	return nil
+}
+`)
+	var src bytes.Buffer
+	tmpl.Execute(&src, map[string]interface{}{
+		"Name":   e.TypeName(),
+		"Raw":    e.Name,
+		"Base":   e.BaseType(),
+		"Values": values,
+		"Consts": strings.Join(consts, ", "),
+	})
+	return src.Bytes()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func TestRefToEnumName(t *testing.T) {
+	cases := []struct {
+		Ref      string
+		Expected string
+	}{
+		{Ref: "#/definitions/task/definitions/status", Expected: "task_status"},
+		{Ref: "#/definitions/error-detail/definitions/code", Expected: "error_detail_code"},
+		{Ref: "", Expected: ""},
+	}
+	for _, c := range cases {
+		if n := refToEnumName(c.Ref); n != c.Expected {
+			t.Errorf("want %s got %s", c.Expected, n)
+		}
+	}
+}
+
+func TestNewEnum(t *testing.T) {
+	sc := schema.New()
+	sc.Type = schema.PrimitiveTypes{schema.StringType}
+	sc.Enum = []interface{}{"done", "in-progress"}
+	e, err := NewEnum("task_status", sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == nil || e.TypeName() != "TaskStatus" || len(e.Values) != 2 {
+		t.Errorf("unexpected enum %v", e)
+	}
+
+	sc.Enum = []interface{}{"done", "in-progress", "in_progress"}
+	_, err = NewEnum("task_status", sc)
+	expected := `enum values "in-progress" and "in_progress" have the same constant name TaskStatusInProgress`
+	if err == nil || err.Error() != expected {
+		t.Errorf("want %s got %v", expected, err)
+	}
+
+	// null is nullability of enum, not a value
+	sc.Enum = []interface{}{"done", nil}
+	e, err = NewEnum("task_status", sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Values) != 1 || e.Values[0] != "done" || !e.Nullable {
+		t.Errorf("want nullable enum of done got %v", e)
+	}
+	if src := string(e.Type()); strings.Contains(src, "Nil") || strings.Contains(src, "<nil>") {
+		t.Errorf("null is generated as enum value: %s", src)
+	}
+	if rules := scalarRules(sc, e, ""); len(rules) != 1 || rules[0] != "oneof=done" {
+		t.Errorf("want oneof=done got %v", rules)
+	}
+	prop := Property{Name: "status", Types: sc.Type, Required: true, Enum: e}
+	if tp := prop.GoType(FormatOption{Optional: OptionalPointer}); tp != "*TaskStatus" {
+		t.Errorf("want *TaskStatus got %s", tp)
+	}
+	if tag := prop.ValidateTag("", FormatOption{Optional: OptionalPointer}); tag != "omitempty,oneof=done" {
+		t.Errorf("want omitempty,oneof=done got %s", tag)
+	}
+
+	sc.Type = schema.PrimitiveTypes{schema.BooleanType}
+	if e, err := NewEnum("task_status", sc); e != nil || err != nil {
+		t.Errorf("want nil got %v, %v", e, err)
+	}
+}
+
+func TestEnumType(t *testing.T) {
+	cases := []struct {
+		enum     Enum
+		expected string
+	}{
+		{
+			enum: Enum{
+				Name:   "task_status",
+				Types:  []schema.PrimitiveType{schema.StringType},
+				Values: []interface{}{"done", "doing", "in-progress"},
+			},
+			expected: `// TaskStatus enum for task_status
+type TaskStatus string
+
+// TaskStatus values
+const (
+	TaskStatusDone       TaskStatus = "done"
+	TaskStatusDoing      TaskStatus = "doing"
+	TaskStatusInProgress TaskStatus = "in-progress"
+)
+
+// Valid returns true if value is one of TaskStatus values
+func (e TaskStatus) Valid() bool {
+	switch e {
+	case TaskStatusDone, TaskStatusDoing, TaskStatusInProgress:
+		return true
+	}
+	return false
+}
+
+// UnmarshalJSON rejects values not defined in TaskStatus
+func (e *TaskStatus) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if !TaskStatus(v).Valid() {
+		return fmt.Errorf("invalid TaskStatus: %v", v)
+	}
+	*e = TaskStatus(v)
+	return nil
+}
+`,
+		},
+		{
+			enum: Enum{
+				Name:   "task_priority",
+				Types:  []schema.PrimitiveType{schema.IntegerType},
+				Values: []interface{}{float64(1), float64(2)},
+			},
+			expected: `// TaskPriority values
+const (
+	TaskPriority1 TaskPriority = 1
+	TaskPriority2 TaskPriority = 2
+)
+`,
+		},
+	}
+	for _, c := range cases {
+		// formatted as fragment like struct command, so that indentation is kept
+		ss, err := format.Source(c.enum.Type())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(ss), c.expected) {
+			t.Errorf("want %s got %s", c.expected, ss)
+		}
+	}
+}
+
+func TestEnumField(t *testing.T) {
+	prop := Property{
+		Name:     "status",
+		Types:    []schema.PrimitiveType{schema.StringType},
+		Required: true,
+		Enum: &Enum{
+			Name:   "task_status",
+			Types:  []schema.PrimitiveType{schema.StringType},
+			Values: []interface{}{"done", "doing"},
+		},
+	}
+	expected := "Status TaskStatus `json:\"status\"`"
+	if f := prop.Field(FormatOption{}); string(f) != expected {
+		t.Errorf("want %s got %s", expected, f)
+	}
+}
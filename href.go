@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/achiku/varfmt"
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+var hrefParamRe = regexp.MustCompile(`{\(([^)]+)\)}`)
+
+// PathParam path parameter in href
+type PathParam struct {
+	Name      string
+	Reference string
+	Types     schema.PrimitiveTypes
+	Format    string
+	Pattern   *regexp.Regexp
+}
+
+// VarName returns go variable name of path parameter
+func (pp PathParam) VarName() string {
+	return varfmt.PrivateVarName(normalize(pp.Name))
+}
+
+// GoType returns go type of path parameter
+func (pp PathParam) GoType() string {
+	switch {
+	case pp.Types.Contains(schema.IntegerType):
+		return "int64"
+	case pp.Types.Contains(schema.NumberType):
+		return "float64"
+	case pp.Types.Contains(schema.BooleanType):
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+// format returns go expression converting path parameter to string
+func (pp PathParam) format() string {
+	switch pp.GoType() {
+	case "int64":
+		return fmt.Sprintf("strconv.FormatInt(%s, 10)", pp.VarName())
+	case "float64":
+		return fmt.Sprintf("strconv.FormatFloat(%s, 'f', -1, 64)", pp.VarName())
+	case "bool":
+		return fmt.Sprintf("strconv.FormatBool(%s)", pp.VarName())
+	default:
+		return fmt.Sprintf("url.PathEscape(%s)", pp.VarName())
+	}
+}
+
+// parseErrCheck returns error of conversion in parse
+const parseErrCheck = "\nif err != nil {\nreturn\n}"
+
+// parse returns go statements converting string s to path parameter
+func (pp PathParam) parse(s string) string {
+	var conv string
+	switch pp.GoType() {
+	case "int64":
+		conv = fmt.Sprintf("%s, err = strconv.ParseInt(%s, 10, 64)", pp.VarName(), s)
+	case "float64":
+		conv = fmt.Sprintf("%s, err = strconv.ParseFloat(%s, 64)", pp.VarName(), s)
+	case "bool":
+		conv = fmt.Sprintf("%s, err = strconv.ParseBool(%s)", pp.VarName(), s)
+	default:
+		return fmt.Sprintf("%s = %s", pp.VarName(), s)
+	}
+	return conv + parseErrCheck
+}
+
+// hrefPattern returns regexp matching href, path parameters are captured
+func hrefPattern(href string) string {
+	parts := hrefParamRe.Split(href, -1)
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return "^" + strings.Join(parts, "([^/]+)") + "$"
+}
+
+// PathFuncName returns name of function building path of action
+func (a *Action) PathFuncName(op FormatOption) string {
+	return a.Name(op) + "Path"
+}
+
+// ParsePathFuncName returns name of function extracting path parameters of action
+func (a *Action) ParsePathFuncName(op FormatOption) string {
+	return "Parse" + a.Name(op) + "Path"
+}
+
+// PathFuncs returns go functions building path and extracting path parameters
+func (a *Action) PathFuncs(op FormatOption) []byte {
+	var (
+		args     []string
+		pathArgs []string
+		parses   []string
+		vars     []string
+	)
+	patternVar := varfmt.PrivateVarName(a.Name(op)) + "PathRegexp"
+	vars = append(vars, fmt.Sprintf("%s = regexp.MustCompile(`%s`)", patternVar, hrefPattern(a.Href)))
+	for i, p := range a.PathParams {
+		args = append(args, p.VarName()+" "+p.GoType())
+		pathArgs = append(pathArgs, p.format())
+		parses = append(parses, p.parse(fmt.Sprintf("m[%d]", i+1)))
+		if p.Pattern != nil && p.GoType() == "string" {
+			v := varfmt.PrivateVarName(a.Name(op)+"_"+normalize(p.Name)) + "Regexp"
+			vars = append(vars, fmt.Sprintf("%s = regexp.MustCompile(%q)", v, p.Pattern.String()))
+			parses = append(parses, fmt.Sprintf(
+				"if !%s.MatchString(%s) {\nerr = fmt.Errorf(\"%%s does not match %%s: %%s\", %q, %s, %s)\nreturn\n}",
+				v, p.VarName(), p.Name, v, p.VarName()))
+		}
+	}
+	// error of last conversion is returned as is
+	if n := len(parses); n != 0 {
+		parses[n-1] = strings.TrimSuffix(parses[n-1], parseErrCheck)
+	}
+	pathFmt := hrefParamRe.ReplaceAllString(a.Href, "%s")
+	var path string
+	if len(a.PathParams) == 0 {
+		path = fmt.Sprintf("%q", pathFmt)
+	} else {
+		path = fmt.Sprintf("fmt.Sprintf(%q, %s)", pathFmt, strings.Join(pathArgs, ", "))
+	}
+
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Parse(`
+// {{ .PathFunc }} returns path of {{ .Method }}: {{ .Href }}
+func {{ .PathFunc }}({{ .Args }}) string {
+	return {{ .Path }}
+}
+{{ if .Parses }}
+var (
+	{{- range .Vars }}
+	{{ . }}
+	{{- end }}
+)
+
+// {{ .ParseFunc }} extracts path parameters of {{ .Method }}: {{ .Href }}
+// from escaped path, e.g. URL.EscapedPath()
+func {{ .ParseFunc }}(path string) ({{ .Args }}, err error) {
+	m := {{ .PatternVar }}.FindStringSubmatch(path)
+	if m == nil {
+		err = fmt.Errorf("path does not match %s: %s", {{ .PatternVar }}, path)
+		return
+	}
+	for i := 1; i < len(m); i++ {
+		if m[i], err = url.PathUnescape(m[i]); err != nil {
+			return
+		}
+	}
+	{{- range .Parses }}
+	{{ . }}
+	{{- end }}
+	return
+}
+{{ end }}
+`)
+	var src bytes.Buffer
+	tmpl.Execute(&src, map[string]interface{}{
+		"PathFunc":   a.PathFuncName(op),
+		"ParseFunc":  a.ParsePathFuncName(op),
+		"PatternVar": patternVar,
+		"Method":     a.Method,
+		"Href":       a.Href,
+		"Args":       strings.Join(args, ", "),
+		"Path":       path,
+		"Parses":     parses,
+		"Vars":       vars,
+	})
+	return src.Bytes()
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"go/format"
+	"regexp"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func TestHrefPattern(t *testing.T) {
+	cases := []struct {
+		Href    string
+		Path    string
+		Matches []string
+	}{
+		{
+			Href:    "/tasks",
+			Path:    "/tasks",
+			Matches: []string{},
+		},
+		{
+			Href:    "/tasks/{(#/definitions/task/definitions/identity)}",
+			Path:    "/tasks/ec0a1edc",
+			Matches: []string{"ec0a1edc"},
+		},
+		{
+			Href:    "/tasks/{(#/definitions/task/definitions/identity)}",
+			Path:    "/tasks/ec0a1edc/users",
+			Matches: nil,
+		},
+	}
+	for _, c := range cases {
+		m := regexp.MustCompile(hrefPattern(c.Href)).FindStringSubmatch(c.Path)
+		if m == nil {
+			if c.Matches != nil {
+				t.Errorf("%s does not match %s", c.Path, c.Href)
+			}
+			continue
+		}
+		if c.Matches == nil {
+			t.Errorf("%s unexpectedly matches %s", c.Path, c.Href)
+			continue
+		}
+		if len(m[1:]) != len(c.Matches) {
+			t.Errorf("want %v got %v", c.Matches, m[1:])
+		}
+	}
+}
+
+func TestPathFuncs(t *testing.T) {
+	cases := []struct {
+		action   Action
+		expected string
+	}{
+		{
+			action: Action{
+				Href:     "/tasks",
+				Method:   "GET",
+				Rel:      "instances",
+				Response: &Resource{Name: "task"},
+			},
+			expected: `// TaskInstancesPath returns path of GET: /tasks
+func TaskInstancesPath() string {
+	return "/tasks"
+}
+`,
+		},
+		{
+			action: Action{
+				Href:   "/tasks/{(#/definitions/task/definitions/identity)}",
+				Method: "GET",
+				Rel:    "self",
+				PathParams: []PathParam{
+					{
+						Name:      "id",
+						Reference: "#/definitions/task/definitions/identity",
+						Types:     []schema.PrimitiveType{schema.StringType},
+					},
+				},
+				Response: &Resource{Name: "task"},
+			},
+			expected: `// TaskSelfPath returns path of GET: /tasks/{(#/definitions/task/definitions/identity)}
+func TaskSelfPath(id string) string {
+	return fmt.Sprintf("/tasks/%s", url.PathEscape(id))
+}
+
+var (
+	taskSelfPathRegexp = regexp.MustCompile(` + "`^/tasks/([^/]+)$`" + `)
+)
+
+// ParseTaskSelfPath extracts path parameters of GET: /tasks/{(#/definitions/task/definitions/identity)}
+// from escaped path, e.g. URL.EscapedPath()
+func ParseTaskSelfPath(path string) (id string, err error) {
+	m := taskSelfPathRegexp.FindStringSubmatch(path)
+	if m == nil {
+		err = fmt.Errorf("path does not match %s: %s", taskSelfPathRegexp, path)
+		return
+	}
+	for i := 1; i < len(m); i++ {
+		if m[i], err = url.PathUnescape(m[i]); err != nil {
+			return
+		}
+	}
+	id = m[1]
+	return
+}
+`,
+		},
+		{
+			action: Action{
+				Href:   "/users/{(#/definitions/user/definitions/identity)}/tasks/{(#/definitions/task/definitions/identity)}",
+				Method: "GET",
+				Rel:    "self",
+				PathParams: []PathParam{
+					{
+						Name:      "user_id",
+						Reference: "#/definitions/user/definitions/identity",
+						Types:     []schema.PrimitiveType{schema.StringType},
+						Pattern:   regexp.MustCompile(`^[a-z0-9-]+$`),
+					},
+					{
+						Name:      "task_id",
+						Reference: "#/definitions/task/definitions/identity",
+						Types:     []schema.PrimitiveType{schema.IntegerType},
+					},
+				},
+				Response: &Resource{Name: "task"},
+			},
+			expected: `// TaskSelfPath returns path of GET: /users/{(#/definitions/user/definitions/identity)}/tasks/{(#/definitions/task/definitions/identity)}
+func TaskSelfPath(userID string, taskID int64) string {
+	return fmt.Sprintf("/users/%s/tasks/%s", url.PathEscape(userID), strconv.FormatInt(taskID, 10))
+}
+
+var (
+	taskSelfPathRegexp   = regexp.MustCompile(` + "`^/users/([^/]+)/tasks/([^/]+)$`" + `)
+	taskSelfUserIDRegexp = regexp.MustCompile("^[a-z0-9-]+$")
+)
+
+// ParseTaskSelfPath extracts path parameters of GET: /users/{(#/definitions/user/definitions/identity)}/tasks/{(#/definitions/task/definitions/identity)}
+// from escaped path, e.g. URL.EscapedPath()
+func ParseTaskSelfPath(path string) (userID string, taskID int64, err error) {
+	m := taskSelfPathRegexp.FindStringSubmatch(path)
+	if m == nil {
+		err = fmt.Errorf("path does not match %s: %s", taskSelfPathRegexp, path)
+		return
+	}
+	for i := 1; i < len(m); i++ {
+		if m[i], err = url.PathUnescape(m[i]); err != nil {
+			return
+		}
+	}
+	userID = m[1]
+	if !taskSelfUserIDRegexp.MatchString(userID) {
+		err = fmt.Errorf("%s does not match %s: %s", "user_id", taskSelfUserIDRegexp, userID)
+		return
+	}
+	taskID, err = strconv.ParseInt(m[2], 10, 64)
+	return
+}
+`,
+		},
+	}
+	for _, c := range cases {
+		// formatted as fragment like struct command, so that indentation is kept
+		src := c.action.PathFuncs(FormatOption{})
+		ss, err := format.Source(src)
+		if err != nil {
+			t.Fatalf("%s: %s", err, src)
+		}
+		expected := "\n" + c.expected + "\n"
+		if string(ss) != expected {
+			t.Errorf("want %s got %s", expected, ss)
+		}
+	}
+}
+
+func TestPathFuncsRoundTrip(t *testing.T) {
+	a := Action{
+		Href:   "/users/{(#/definitions/user/definitions/identity)}/tasks/{(#/definitions/task/definitions/identity)}",
+		Method: "GET",
+		Rel:    "self",
+		PathParams: []PathParam{
+			{Name: "user_id", Types: []schema.PrimitiveType{schema.StringType}},
+			{Name: "task_id", Types: []schema.PrimitiveType{schema.IntegerType}},
+		},
+		Response: &Resource{Name: "task"},
+	}
+	src := []byte(`package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+`)
+	src = append(src, a.PathFuncs(FormatOption{})...)
+	src = append(src, []byte(`
+func main() {
+	for _, id := range []string{"a/b", "100%", "x y", "?#"} {
+		path := TaskSelfPath(id, 3)
+		u, err := url.Parse("http://example.com" + path)
+		if err != nil {
+			panic(err)
+		}
+		userID, taskID, err := ParseTaskSelfPath(u.EscapedPath())
+		fmt.Printf("%s %q %d %v\n", path, userID, taskID, err)
+	}
+}
+`)...)
+	expected := `/users/a%2Fb/tasks/3 "a/b" 3 <nil>
+/users/100%25/tasks/3 "100%" 3 <nil>
+/users/x%20y/tasks/3 "x y" 3 <nil>
+/users/%3F%23/tasks/3 "?#" 3 <nil>
+`
+	if out := runGo(t, src); out != expected {
+		t.Errorf("want %s got %s", expected, out)
+	}
+}
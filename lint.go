@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	hschema "github.com/lestrrat-go/jshschema"
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// LintError violation of prmd conventions at JSON pointer
+type LintError struct {
+	Pointer string
+	Message string
+}
+
+func (e LintError) Error() string {
+	return e.Pointer + ": " + e.Message
+}
+
+// Linter collects violations of prmd conventions prmdg assumes
+type Linter struct {
+	root     *schema.Schema
+	useTitle bool
+	errs     []LintError
+}
+
+// NewLinter creates linter. link titles are required if useTitle
+func NewLinter(root *schema.Schema, useTitle bool) *Linter {
+	return &Linter{
+		root:     root,
+		useTitle: useTitle,
+	}
+}
+
+func (l *Linter) add(ptr string, format string, args ...interface{}) {
+	l.errs = append(l.errs, LintError{Pointer: ptr, Message: fmt.Sprintf(format, args...)})
+}
+
+func sortedSchemaKeys(m map[string]*schema.Schema) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Lint walks resources and their links the same way as ParseResources and
+// ParseActions, and returns all violations in the order of the walk
+func (l *Linter) Lint() []LintError {
+	l.errs = nil
+	for _, id := range sortedSchemaKeys(l.root.Definitions) {
+		ptr := "/definitions/" + pointerEscaper.Replace(id)
+		df := l.root.Definitions[id]
+		l.lintResource(ptr, df)
+
+		hsc := hschema.New()
+		if err := hsc.Extract(df.Extras); err != nil {
+			l.add(ptr+"/links", "failed to extract links: %s", err)
+			continue
+		}
+		names := make(map[string]string)
+		for i, link := range hsc.Links {
+			l.lintLink(fmt.Sprintf("%s/links/%d", ptr, i), link, names)
+		}
+	}
+	return l.errs
+}
+
+// lintResource checks main resource directly under definitions
+func (l *Linter) lintResource(ptr string, df *schema.Schema) {
+	if df.Reference != "" {
+		l.add(ptr, "main resource has to be defined directly under definitions, not by $ref")
+		return
+	}
+	if !df.Type.Contains(schema.ObjectType) && len(df.AllOf) == 0 {
+		l.add(ptr+"/type", "main resource has to be an object")
+	}
+	l.lintSchema(ptr, df)
+}
+
+// lintLink checks link of resource. names holds pointers of links by name already
+// seen in the resource to detect actions generated with the same name
+func (l *Linter) lintLink(ptr string, link *hschema.Link, names map[string]string) {
+	if link.Rel == "" {
+		l.add(ptr, "rel is not defined")
+	}
+	name := link.Rel
+	if l.useTitle {
+		name = link.Title
+		if link.Title == "" {
+			l.add(ptr, "title is not defined, required by --use-title")
+		}
+	}
+	if name != "" {
+		if p, ok := names[name]; ok {
+			l.add(ptr, "%s is also used by %s", name, p)
+		} else {
+			names[name] = ptr
+		}
+	}
+	if link.Href == "" {
+		l.add(ptr, "href is not defined")
+	} else {
+		l.lintHref(ptr+"/href", link.Href)
+	}
+	l.lintSchema(ptr+"/schema", link.Schema)
+	l.lintSchema(ptr+"/targetSchema", link.TargetSchema)
+}
+
+// lintHref checks references to path parameter definitions in href template
+func (l *Linter) lintHref(ptr string, href string) {
+	h, err := url.QueryUnescape(href)
+	if err != nil {
+		l.add(ptr, "failed to unescape: %s", err)
+		return
+	}
+	for _, m := range hrefParamRe.FindAllStringSubmatch(h, -1) {
+		sc := schema.New()
+		sc.Reference = m[1]
+		if _, err := resolveSchema(sc, l.root); err != nil {
+			l.add(ptr, "unresolvable reference %s: %s", m[1], err)
+		}
+	}
+}
+
+// unsupportedKeywords returns keywords of schema ignored by prmdg
+func unsupportedKeywords(sc *schema.Schema) []string {
+	var kws []string
+	if sc.Not != nil {
+		kws = append(kws, "not")
+	}
+	if len(sc.PatternProperties) != 0 {
+		kws = append(kws, "patternProperties")
+	}
+	if len(sc.Dependencies.Names) != 0 || len(sc.Dependencies.Schemas) != 0 {
+		kws = append(kws, "dependencies")
+	}
+	if sc.AdditionalItems != nil && sc.AdditionalItems.Schema != nil {
+		kws = append(kws, "additionalItems")
+	}
+	return kws
+}
+
+// lintSchema checks schema and its sub schemas. references are checked to be
+// resolvable, and referred schemas are checked where they are defined
+func (l *Linter) lintSchema(ptr string, sc *schema.Schema) {
+	if sc == nil {
+		return
+	}
+	if sc.Reference != "" {
+		if _, err := resolveSchema(sc, l.root); err != nil {
+			l.add(ptr+"/$ref", "unresolvable reference %s: %s", sc.Reference, err)
+		}
+		return
+	}
+	for _, kw := range unsupportedKeywords(sc) {
+		l.add(ptr+"/"+kw, "%s is not supported", kw)
+	}
+	if sc.Type.Contains(schema.ArrayType) {
+		switch {
+		case sc.Items == nil || len(sc.Items.Schemas) == 0:
+			l.add(ptr, "array has to have items")
+		case sc.Items.TupleMode || len(sc.Items.Schemas) > 1:
+			l.add(ptr+"/items", "tuple items are not supported, array has to have one item schema")
+		}
+	}
+	if sc.Items != nil {
+		for i, item := range sc.Items.Schemas {
+			p := ptr + "/items"
+			if sc.Items.TupleMode {
+				p += "/" + strconv.Itoa(i)
+			}
+			l.lintSchema(p, item)
+		}
+	}
+	for i, s := range sc.AllOf {
+		l.lintSchema(fmt.Sprintf("%s/allOf/%d", ptr, i), s)
+	}
+	for i, s := range sc.AnyOf {
+		l.lintSchema(fmt.Sprintf("%s/anyOf/%d", ptr, i), s)
+	}
+	for i, s := range sc.OneOf {
+		l.lintSchema(fmt.Sprintf("%s/oneOf/%d", ptr, i), s)
+	}
+	if sc.AdditionalProperties != nil && sc.AdditionalProperties.Schema != nil {
+		l.lintSchema(ptr+"/additionalProperties", sc.AdditionalProperties.Schema)
+	}
+	for _, name := range sortedSchemaKeys(sc.Definitions) {
+		l.lintSchema(ptr+"/definitions/"+pointerEscaper.Replace(name), sc.Definitions[name])
+	}
+	for _, name := range sortedSchemaKeys(sc.Properties) {
+		l.lintSchema(ptr+"/properties/"+pointerEscaper.Replace(name), sc.Properties[name])
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	hschema "github.com/lestrrat-go/jshschema"
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+// equalLintErrors compares errors by pointer and message prefix, since messages of
+// unresolvable references end with error of jsschema
+func equalLintErrors(errs, expected []LintError) bool {
+	if len(errs) != len(expected) {
+		return false
+	}
+	for i, e := range errs {
+		if e.Pointer != expected[i].Pointer || !strings.HasPrefix(e.Message, expected[i].Message) {
+			return false
+		}
+	}
+	return true
+}
+
+func lintTestSchema() *schema.Schema {
+	id := schema.New()
+	id.Type = schema.PrimitiveTypes{schema.StringType}
+
+	ref := func(r string) *schema.Schema {
+		sc := schema.New()
+		sc.Reference = r
+		return sc
+	}
+	array := func(items *schema.ItemSpec) *schema.Schema {
+		sc := schema.New()
+		sc.Type = schema.PrimitiveTypes{schema.ArrayType}
+		sc.Items = items
+		return sc
+	}
+	not := schema.New()
+	not.Type = schema.PrimitiveTypes{schema.StringType}
+	not.Not = schema.New()
+
+	task := schema.New()
+	task.Type = schema.PrimitiveTypes{schema.ObjectType}
+	task.Definitions = map[string]*schema.Schema{"id": id}
+	task.Properties = map[string]*schema.Schema{
+		"id":      ref("#/definitions/task/definitions/id"),
+		"owner":   ref("#/definitions/user"),
+		"tags":    array(nil),
+		"pair":    array(&schema.ItemSpec{TupleMode: true, Schemas: schema.SchemaList{id, id}}),
+		"a/b":     not,
+		"members": array(&schema.ItemSpec{Schemas: schema.SchemaList{ref("#/definitions/task")}}),
+	}
+
+	root := schema.New()
+	root.Definitions = map[string]*schema.Schema{
+		"task":  task,
+		"alias": ref("#/definitions/task"),
+	}
+	return root
+}
+
+func TestLint(t *testing.T) {
+	errs := NewLinter(lintTestSchema(), false).Lint()
+	expected := []LintError{
+		{Pointer: "/definitions/alias", Message: "main resource has to be defined directly under definitions, not by $ref"},
+		{Pointer: "/definitions/task/properties/a~1b/not", Message: "not is not supported"},
+		{Pointer: "/definitions/task/properties/owner/$ref", Message: "unresolvable reference #/definitions/user: "},
+		{Pointer: "/definitions/task/properties/pair/items", Message: "tuple items are not supported, array has to have one item schema"},
+		{Pointer: "/definitions/task/properties/tags", Message: "array has to have items"},
+	}
+	if !equalLintErrors(errs, expected) {
+		t.Errorf("want %v got %v", expected, errs)
+	}
+}
+
+func TestLintLink(t *testing.T) {
+	cases := []struct {
+		useTitle bool
+		links    []*hschema.Link
+		expected []LintError
+	}{
+		{
+			links: []*hschema.Link{
+				{Rel: "self", Href: "/tasks/{(%23%2Fdefinitions%2Ftask%2Fdefinitions%2Fid)}"},
+				{Href: "/tasks"},
+			},
+			expected: []LintError{
+				{Pointer: "/definitions/task/links/1", Message: "rel is not defined"},
+			},
+		},
+		{
+			links: []*hschema.Link{
+				{Rel: "create", Href: "/tasks"},
+				{Rel: "create", Href: "/tasks/{(%23%2Fdefinitions%2Ftask%2Fdefinitions%2Fidentity)}"},
+			},
+			expected: []LintError{
+				{Pointer: "/definitions/task/links/1", Message: "create is also used by /definitions/task/links/0"},
+				{Pointer: "/definitions/task/links/1/href", Message: "unresolvable reference #/definitions/task/definitions/identity: "},
+			},
+		},
+		{
+			useTitle: true,
+			links: []*hschema.Link{
+				{Rel: "create", Title: "Create", Href: "/tasks"},
+				{Rel: "self", Href: "/tasks"},
+			},
+			expected: []LintError{
+				{Pointer: "/definitions/task/links/1", Message: "title is not defined, required by --use-title"},
+			},
+		},
+	}
+	for _, c := range cases {
+		l := NewLinter(lintTestSchema(), c.useTitle)
+		names := make(map[string]string)
+		for i, link := range c.links {
+			l.lintLink("/definitions/task/links/"+strconv.Itoa(i), link, names)
+		}
+		if !equalLintErrors(l.errs, c.expected) {
+			t.Errorf("want %v got %v", c.expected, l.errs)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"sort"
 
+	"github.com/achiku/varfmt"
 	schema "github.com/lestrrat-go/jsschema"
 	"github.com/lestrrat-go/jsval"
 	"github.com/pkg/errors"
@@ -24,7 +25,7 @@ const (
 var (
 	app = kingpin.New("prmdg", "prmd generated JSON Hyper Schema to Go")
 	pkg = app.Flag("package", "package name for Go file").Default("main").Short('p').String()
-	fp  = app.Flag("file", "path JSON Schema").Required().Short('f').String()
+	fp  = app.Flag("file", "path JSON Schema, or directory of prmd schemata and meta.yml").Required().Short('f').String()
 	op  = app.Flag("output", "path to Go output file").Short('o').String()
 
 	structCmd = app.Command("struct", "generate struct file")
@@ -32,10 +33,29 @@ var (
 		"jsval", "generate validator file using github.com/lestrrat-go/go-jsval")
 	validatorCmd = app.Command(
 		"validator", "generate validator file using github.com/go-playground/validator")
+	clientCmd     = app.Command("client", "generate API client file")
+	serverCmd     = app.Command("server", "generate server handler interfaces and router file")
+	middlewareCmd = app.Command(
+		"middleware", "generate net/http middleware validating requests with validators generated by jsval")
+	combineCmd = app.Command("combine", "combine directory of prmd schemata and meta.yml into JSON Schema file")
+	lintCmd    = app.Command("lint", "report violations of prmd conventions prmdg assumes")
 
 	scValidator = structCmd.Flag("validate-tag", "add `validate` tag to struct").Bool()
 	scUseTitle  = structCmd.Flag("use-title", "use title tag in request/response struct name").Bool()
-	scNullable  = structCmd.Flag("nullable", "use github.com/guregu/null for null value").Bool()
+	scNullable  = structCmd.Flag("nullable", "use github.com/guregu/null for null value, same as --optional=null").Bool()
+	scOptional  = structCmd.Flag("optional", "go type of non-required or nullable value").Enum(
+		string(OptionalPointer), string(OptionalNull), string(OptionalSQLNull), string(OptionalGeneric))
+	scReadWrite = structCmd.Flag("read-write", "generate read models and create/update input structs honoring readOnly/writeOnly").Bool()
+	scTypeMap   = structCmd.Flag("type-map", "map format to go type, e.g. uuid=github.com/google/uuid.UUID").Strings()
+	scError     = structCmd.Flag("error", "resource name generated as go error type").String()
+
+	jvResponse = jsValCmd.Flag("response", "generate response validators from targetSchema").Bool()
+
+	clUseTitle = clientCmd.Flag("use-title", "use title tag in request/response struct name").Bool()
+	clError    = clientCmd.Flag("error", "resource name decoded from non-2xx response").String()
+	svUseTitle = serverCmd.Flag("use-title", "use title tag in request/response struct name").Bool()
+	mwError    = middlewareCmd.Flag("error", "resource name of error response").String()
+	lnUseTitle = lintCmd.Flag("use-title", "require title of links used in request/response struct name").Bool()
 )
 
 func main() {
@@ -54,24 +74,59 @@ func main() {
 	} else {
 		out = os.Stdout
 	}
-	in, err = os.Open(*fp)
+	if cmd == combineCmd.FullCommand() {
+		if err := generateCombinedFile(*fp, out, *op); err != nil {
+			app.Errorf("failed to generate combined schema file: %s", err)
+		}
+		return
+	}
+
+	in, err = openSchema(*fp, *op)
 	if err != nil {
 		app.Errorf("failed to open input file %s: %s", *fp, err)
 	}
 
 	switch cmd {
 	case structCmd.FullCommand():
-		if err := generateStructFile(pkg, in, out, *scValidator, *scUseTitle, *scNullable); err != nil {
+		tm, err := ParseTypeMap(*scTypeMap)
+		if err != nil {
+			app.Errorf("failed to parse type map: %s", err)
+		}
+		optional, err := ParseOptional(*scOptional)
+		if err != nil {
+			app.Errorf("failed to parse optional mode: %s", err)
+		}
+		if *scNullable && optional == OptionalNone {
+			optional = OptionalNull
+		}
+		if err := generateStructFile(pkg, in, out, *scValidator, *scUseTitle, *scReadWrite, optional, tm, *scError); err != nil {
 			app.Errorf("failed to generate struct file: %s", err)
 		}
 	case jsValCmd.FullCommand():
-		if err := generateJsValValidatorFile(pkg, in, out); err != nil {
+		if err := generateJsValValidatorFile(pkg, in, out, *jvResponse); err != nil {
 			app.Errorf("failed to generate jsval validator file: %s", err)
 		}
 	case validatorCmd.FullCommand():
 		if err := generateValidatorFile(pkg, in, out); err != nil {
 			app.Errorf("failed to generate validator file: %s", err)
 		}
+	case clientCmd.FullCommand():
+		if err := generateClientFile(pkg, in, out, *clUseTitle, *clError); err != nil {
+			app.Errorf("failed to generate client file: %s", err)
+		}
+	case serverCmd.FullCommand():
+		if err := generateServerFile(pkg, in, out, *svUseTitle); err != nil {
+			app.Errorf("failed to generate server file: %s", err)
+		}
+	case middlewareCmd.FullCommand():
+		if err := generateMiddlewareFile(pkg, in, out, *mwError); err != nil {
+			app.Errorf("failed to generate middleware file: %s", err)
+		}
+	case lintCmd.FullCommand():
+		if err := lintSchemaFile(in, out, *lnUseTitle); err != nil {
+			app.Fatalf("failed to lint schema: %s", err)
+		}
+		return
 	}
 
 	if *op != "" {
@@ -83,12 +138,11 @@ func main() {
 }
 
 func generateValidatorFile(pkg *string, fp io.Reader, op io.Writer) error {
-	sc, err := schema.Read(fp)
+	parser, err := ReadParser(fp, *pkg)
 	if err != nil {
 		log.Printf("%s", err)
 		return errors.Wrapf(err, "failed to read %s", fp)
 	}
-	parser := NewParser(sc, *pkg)
 	vals, err := parser.ParseValidators()
 	if err != nil {
 		return err
@@ -107,13 +161,17 @@ func generateValidatorFile(pkg *string, fp io.Reader, op io.Writer) error {
 	return nil
 }
 
-func generateStructFile(pkg *string, fp io.Reader, op io.Writer, val, useTitle, nullable bool) error {
-	sc, err := schema.Read(fp)
+func generateStructFile(pkg *string, fp io.Reader, op io.Writer, val, useTitle, readWrite bool, optional Optional, tm TypeMap, errName string) error {
+	parser, err := ReadParser(fp, *pkg)
 	if err != nil {
 		return errors.Wrapf(err, "failed to read %s", fp)
 	}
-	parser := NewParser(sc, *pkg)
-	resources, err := parser.ParseResources()
+	var resources map[string]Resource
+	if readWrite {
+		resources, err = parser.ParseReadWriteResources()
+	} else {
+		resources, err = parser.ParseResources()
+	}
 	if err != nil {
 		return err
 	}
@@ -121,6 +179,11 @@ func generateStructFile(pkg *string, fp io.Reader, op io.Writer, val, useTitle,
 	if err != nil {
 		return err
 	}
+	if readWrite {
+		if err := ValidateReadOnly(links); err != nil {
+			return err
+		}
+	}
 
 	var src []byte
 	src = append(src, []byte(fmt.Sprintf("package %s\n\n", *pkg))...)
@@ -133,7 +196,8 @@ func generateStructFile(pkg *string, fp io.Reader, op io.Writer, val, useTitle,
 		Validator: val,
 		Schema:    false,
 		UseTitle:  useTitle,
-		UseNull:   nullable,
+		Optional:  optional,
+		TypeMap:   tm,
 	}
 	sort.Strings(resKeys)
 	for _, k := range resKeys {
@@ -144,12 +208,78 @@ func generateStructFile(pkg *string, fp io.Reader, op io.Writer, val, useTitle,
 		}
 		src = append(src, ss...)
 	}
+	errRes, err := errorResource(resources, errName)
+	if err != nil {
+		return err
+	}
+	if errRes != nil {
+		ss, err := format.Source(ErrorFuncs(errRes, stOpt))
+		if err != nil {
+			return errors.Wrapf(err, "failed to format error resource: %s", errRes.Name)
+		}
+		src = append(src, ss...)
+	}
 
 	var linkKeys []string
 	for key := range links {
 		linkKeys = append(linkKeys, key)
 	}
 	sort.Strings(linkKeys)
+
+	enums := make(map[string]*Enum)
+	unions := make(map[string]*Union)
+	structs := make(map[string]*Property)
+	var typeRes []Resource
+	for _, k := range resKeys {
+		typeRes = append(typeRes, resources[k])
+	}
+	for _, k := range linkKeys {
+		for _, action := range links[k] {
+			for _, r := range []*Resource{action.Request, action.Response} {
+				if r != nil {
+					typeRes = append(typeRes, *r)
+				}
+			}
+		}
+	}
+	var goTypes []GoType
+	for _, r := range typeRes {
+		props := r.Properties
+		if r.AdditionalProperties != nil {
+			props = append([]*Property{r.AdditionalProperties}, props...)
+		}
+		if err := collectTypes(props, enums, unions, structs); err != nil {
+			return err
+		}
+		goTypes = append(goTypes, collectGoTypes(props)...)
+	}
+	for _, e := range sortEnums(enums) {
+		ss, err := format.Source(e.Type())
+		if err != nil {
+			return errors.Wrapf(err, "failed to format enum: %s", e.Name)
+		}
+		src = append(src, ss...)
+	}
+	for _, u := range sortUnions(unions) {
+		ss, err := format.Source(u.Type(stOpt))
+		if err != nil {
+			return errors.Wrapf(err, "failed to format union: %s", u.Name)
+		}
+		src = append(src, ss...)
+	}
+	var structNames []string
+	for n := range structs {
+		structNames = append(structNames, n)
+	}
+	sort.Strings(structNames)
+	for _, n := range structNames {
+		ss, err := format.Source(structs[n].RecursiveStruct(stOpt))
+		if err != nil {
+			return errors.Wrapf(err, "failed to format recursive struct: %s", n)
+		}
+		src = append(src, ss...)
+	}
+
 	for _, k := range linkKeys {
 		actions := links[k]
 		for _, action := range actions {
@@ -160,14 +290,16 @@ func generateStructFile(pkg *string, fp io.Reader, op io.Writer, val, useTitle,
 					Validator: val,
 					Schema:    true,
 					UseTitle:  useTitle,
-					UseNull:   nullable,
+					Optional:  optional,
+					TypeMap:   tm,
 				}
 			default:
 				reqOpt = FormatOption{
 					Validator: val,
 					Schema:    false,
 					UseTitle:  useTitle,
-					UseNull:   nullable,
+					Optional:  optional,
+					TypeMap:   tm,
 				}
 			}
 			req, err := format.Source(action.RequestStruct(reqOpt))
@@ -180,22 +312,52 @@ func generateStructFile(pkg *string, fp io.Reader, op io.Writer, val, useTitle,
 				return errors.Wrapf(err, "failed to format response struct: %s, %s", k, action.Href)
 			}
 			src = append(src, resp...)
+			path, err := format.Source(action.PathFuncs(reqOpt))
+			if err != nil {
+				return errors.Wrapf(err, "failed to format path functions: %s, %s", k, action.Href)
+			}
+			src = append(src, path...)
+		}
+	}
+
+	if optional == OptionalGeneric && bytes.Contains(src, []byte("Optional[")) {
+		src = append(src, []byte(optionalType)...)
+	}
+	if optional == OptionalSQLNull {
+		wrappers, err := format.Source(sqlNullWrappers(src))
+		if err != nil {
+			return errors.Wrap(err, "failed to format sql null types")
+		}
+		src = append(src, wrappers...)
+	}
+
+	if imports := tm.Imports(src, goTypes...); len(imports) != 0 {
+		decl := []byte("import (\n")
+		for _, imp := range imports {
+			decl = append(decl, []byte(imp+"\n")...)
 		}
+		decl = append(decl, []byte(")\n\n")...)
+		head := []byte(fmt.Sprintf("package %s\n\n", *pkg))
+		src = append(head, append(decl, src[len(head):]...)...)
 	}
 
+	// join fragments formatted separately
+	src, err = format.Source(src)
+	if err != nil {
+		return errors.Wrap(err, "failed to format struct file")
+	}
 	if _, err := op.Write(src); err != nil {
 		return err
 	}
 	return nil
 }
 
-func generateJsValValidatorFile(pkg *string, fp io.Reader, op io.Writer) error {
-	sc, err := schema.Read(fp)
+func generateJsValValidatorFile(pkg *string, fp io.Reader, op io.Writer, response bool) error {
+	parser, err := ReadParser(fp, *pkg)
 	if err != nil {
 		return errors.Wrapf(err, "failed to read %s", fp)
 	}
-	parser := NewParser(sc, *pkg)
-	validators, err := parser.ParseJsValValidators()
+	validators, err := parser.ParseJsValValidators(response)
 	if err != nil {
 		return err
 	}
@@ -211,3 +373,144 @@ func generateJsValValidatorFile(pkg *string, fp io.Reader, op io.Writer) error {
 	}
 	return nil
 }
+
+func generateClientFile(pkg *string, fp io.Reader, op io.Writer, useTitle bool, errName string) error {
+	parser, err := ReadParser(fp, *pkg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", fp)
+	}
+	resources, err := parser.ParseResources()
+	if err != nil {
+		return err
+	}
+	links, err := parser.ParseActions(resources)
+	if err != nil {
+		return err
+	}
+
+	errRes, err := errorResource(resources, errName)
+	if err != nil {
+		return err
+	}
+	var errType string
+	if errRes != nil {
+		errType = varfmt.PublicVarName(normalize(errRes.Name))
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n\n", *pkg)
+	src.Write(Client(errType))
+
+	var linkKeys []string
+	for key := range links {
+		linkKeys = append(linkKeys, key)
+	}
+	sort.Strings(linkKeys)
+	opt := FormatOption{UseTitle: useTitle}
+	for _, k := range linkKeys {
+		for _, action := range links[k] {
+			src.Write(action.ClientMethod(opt))
+		}
+	}
+	ss, err := format.Source(src.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "failed to format client")
+	}
+
+	if _, err := op.Write(ss); err != nil {
+		return err
+	}
+	return nil
+}
+
+func generateServerFile(pkg *string, fp io.Reader, op io.Writer, useTitle bool) error {
+	parser, err := ReadParser(fp, *pkg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", fp)
+	}
+	resources, err := parser.ParseResources()
+	if err != nil {
+		return err
+	}
+	links, err := parser.ParseActions(resources)
+	if err != nil {
+		return err
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n\n", *pkg)
+	src.Write(Server(links, FormatOption{UseTitle: useTitle}))
+	ss, err := format.Source(src.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "failed to format server")
+	}
+
+	if _, err := op.Write(ss); err != nil {
+		return err
+	}
+	return nil
+}
+
+func generateMiddlewareFile(pkg *string, fp io.Reader, op io.Writer, errID string) error {
+	parser, err := ReadParser(fp, *pkg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", fp)
+	}
+	resources, err := parser.ParseResources()
+	if err != nil {
+		return err
+	}
+	links, err := parser.ParseActions(resources)
+	if err != nil {
+		return err
+	}
+	errRes, err := errorResource(resources, errID)
+	if err != nil {
+		return err
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n\n", *pkg)
+	src.Write(Middleware(links, errRes))
+	ss, err := format.Source(src.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "failed to format middleware")
+	}
+
+	if _, err := op.Write(ss); err != nil {
+		return err
+	}
+	return nil
+}
+
+func generateCombinedFile(dir string, op io.Writer, output string) error {
+	root, err := CombineSchemata(dir, output)
+	if err != nil {
+		return err
+	}
+	b, err := MarshalSchema(root)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode combined schema")
+	}
+	if _, err := op.Write(b); err != nil {
+		return err
+	}
+	return nil
+}
+
+func lintSchemaFile(fp io.Reader, op io.Writer, useTitle bool) error {
+	sc, err := schema.Read(fp)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", fp)
+	}
+	errs := NewLinter(sc, useTitle).Lint()
+	for _, e := range errs {
+		if _, err := fmt.Fprintln(op, e.Error()); err != nil {
+			return err
+		}
+	}
+	if len(errs) != 0 {
+		return errors.Errorf("%d violations found", len(errs))
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"go/format"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -8,25 +10,39 @@ import (
 
 func TestGenerateStructFile(t *testing.T) {
 	pkg := "taskyapi"
-	op := ioutil.Discard
 	cases := []struct {
 		Validator bool
 		UseTitle  bool
-		Nullable  bool
+		ReadWrite bool
+		Optional  Optional
 	}{
-		{Validator: false, UseTitle: false, Nullable: false},
-		{Validator: true, UseTitle: false, Nullable: false},
-		{Validator: true, UseTitle: true, Nullable: true},
+		{Validator: false, UseTitle: false, Optional: OptionalNone},
+		{Validator: false, UseTitle: false, ReadWrite: true, Optional: OptionalNone},
+		{Validator: true, UseTitle: true, ReadWrite: true, Optional: OptionalPointer},
+		{Validator: true, UseTitle: false, Optional: OptionalNone},
+		{Validator: true, UseTitle: true, Optional: OptionalNull},
+		{Validator: true, UseTitle: true, Optional: OptionalPointer},
+		{Validator: true, UseTitle: true, Optional: OptionalSQLNull},
+		{Validator: true, UseTitle: true, Optional: OptionalGeneric},
 	}
 	for _, c := range cases {
 		fp, err := os.Open("./example/doc/schema/schema.json")
 		if err != nil {
 			t.Fatal(err)
 		}
-		if err := generateStructFile(&pkg, fp, op, c.Validator, c.UseTitle, c.Nullable); err != nil {
+		var out bytes.Buffer
+		if err := generateStructFile(&pkg, fp, &out, c.Validator, c.UseTitle, c.ReadWrite, c.Optional, nil, "error"); err != nil {
 			t.Fatal(err)
 		}
 		fp.Close()
+		// generated code is flush-left and formatted as a whole
+		ss, err := format.Source(out.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out.Bytes(), ss) {
+			t.Errorf("not formatted: %+v", c)
+		}
 	}
 }
 
@@ -38,8 +54,13 @@ func TestGenerateJsValValidatorFile(t *testing.T) {
 	}
 	defer fp.Close()
 	op := ioutil.Discard
-	if err := generateJsValValidatorFile(&pkg, fp, op); err != nil {
-		t.Fatal(err)
+	for _, response := range []bool{false, true} {
+		if _, err := fp.Seek(0, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := generateJsValValidatorFile(&pkg, fp, op, response); err != nil {
+			t.Fatal(err)
+		}
 	}
 }
 
@@ -55,3 +76,44 @@ func TestGenerateValidatorFile(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestGenerateClientFile(t *testing.T) {
+	pkg := "taskyapi"
+	for _, useTitle := range []bool{false, true} {
+		fp, err := os.Open("./example/doc/schema/schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		op := ioutil.Discard
+		if err := generateClientFile(&pkg, fp, op, useTitle, "error"); err != nil {
+			t.Fatal(err)
+		}
+		fp.Close()
+	}
+}
+
+func TestGenerateServerFile(t *testing.T) {
+	pkg := "taskyapi"
+	fp, err := os.Open("./example/doc/schema/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	op := ioutil.Discard
+	if err := generateServerFile(&pkg, fp, op, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateMiddlewareFile(t *testing.T) {
+	pkg := "taskyapi"
+	fp, err := os.Open("./example/doc/schema/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	op := ioutil.Discard
+	if err := generateMiddlewareFile(&pkg, fp, op, ""); err != nil {
+		t.Fatal(err)
+	}
+}
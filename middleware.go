@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/achiku/varfmt"
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+// middlewareBase is the middleware and its helpers shared by all validators
+const middlewareBase = `
+type requestValidator struct {
+	method    string
+	pattern   *regexp.Regexp
+	source    string
+	types     map[string]string
+	validator *jsval.JSVal
+}
+
+// ValidateRequest returns middleware validating requests with generated validators.
+// requests not matching any link are passed to next as is
+func ValidateRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, v := range requestValidators {
+			if v.method != r.Method || !v.pattern.MatchString(r.URL.Path) {
+				continue
+			}
+			params, err := requestParams(r, v.source, v.types)
+			if err == nil {
+				if err = v.validator.Validate(params); err != nil {
+					err = propertyError(err)
+				}
+			}
+			if err != nil {
+				writeValidationError(w, err)
+				return
+			}
+			break
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestFieldError validation failure of request field, filling field errors of
+// error resource
+type requestFieldError struct {
+	field string
+	err   error
+}
+
+func (e *requestFieldError) Error() string {
+	return e.err.Error()
+}
+
+// Field returns path of invalid field, e.g. owner.name
+func (e *requestFieldError) Field() string {
+	return e.field
+}
+
+var jsvalPropertyRegexp = regexp.MustCompile("^object property '([^']+)' (?:validation failed: )?")
+
+// propertyError returns error of jsval with path of property failed, or err as is
+// if it is not failure of property
+func propertyError(err error) error {
+	var path []string
+	msg := err.Error()
+	for {
+		m := jsvalPropertyRegexp.FindStringSubmatch(msg)
+		if m == nil {
+			break
+		}
+		path = append(path, m[1])
+		msg = msg[len(m[0]):]
+	}
+	if len(path) == 0 {
+		return err
+	}
+	return &requestFieldError{field: strings.Join(path, "."), err: err}
+}
+
+// requestParams returns JSON body, or query/form parameters converted by schema types
+func requestParams(r *http.Request, source string, types map[string]string) (interface{}, error) {
+	var values url.Values
+	switch source {
+	case "query":
+		values = r.URL.Query()
+	case "form":
+		if err := r.ParseForm(); err != nil {
+			return nil, err
+		}
+		values = r.PostForm
+	default:
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(b))
+		if len(bytes.TrimSpace(b)) == 0 {
+			return map[string]interface{}{}, nil
+		}
+		var params interface{}
+		if err := json.Unmarshal(b, &params); err != nil {
+			return nil, err
+		}
+		return params, nil
+	}
+	params := make(map[string]interface{})
+	for k, vs := range values {
+		var converted []interface{}
+		for _, v := range vs {
+			c, err := convertParam(v, types[k])
+			if err != nil {
+				return nil, &requestFieldError{field: k, err: fmt.Errorf("invalid parameter %s: %s", k, err)}
+			}
+			converted = append(converted, c)
+		}
+		if types[k] == "array" || len(converted) > 1 {
+			params[k] = converted
+		} else {
+			params[k] = converted[0]
+		}
+	}
+	return params, nil
+}
+
+func convertParam(v, typ string) (interface{}, error) {
+	switch typ {
+	case "integer", "number":
+		return strconv.ParseFloat(v, 64)
+	case "boolean":
+		return strconv.ParseBool(v)
+	default:
+		return v, nil
+	}
+}
+`
+
+// middlewareImports imports used by generated middleware file
+var middlewareImports = []string{
+	"bytes",
+	"encoding/json",
+	"fmt",
+	"io/ioutil",
+	"net/http",
+	"net/url",
+	"regexp",
+	"strconv",
+	"strings",
+	"",
+	"github.com/lestrrat-go/go-jsval",
+}
+
+// validationErrorWriter returns go function writing validation error as error resource
+// built by its constructor generated by struct command, or {"message": ...} if error
+// resource is not designated
+func validationErrorWriter(errRes *Resource) []byte {
+	var name string
+	if errRes != nil {
+		name = varfmt.PublicVarName(normalize(errRes.Name))
+	}
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Parse(`
+// writeValidationError writes validation error with status 400
+func writeValidationError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	{{- if . }}
+	json.NewEncoder(w).Encode(New{{ . }}FromValidation(err))
+	{{- else }}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": err.Error(),
+	})
+	{{- end }}
+}
+`)
+	var src bytes.Buffer
+	tmpl.Execute(&src, name)
+	return src.Bytes()
+}
+
+// paramTypes returns go map literal of request parameter types
+func paramTypes(props []*Property) string {
+	var kvs []string
+	for _, p := range props {
+		var t string
+		switch {
+		case p.Types.Contains(schema.ArrayType):
+			t = "array"
+		case p.Types.Contains(schema.IntegerType):
+			t = "integer"
+		case p.Types.Contains(schema.NumberType):
+			t = "number"
+		case p.Types.Contains(schema.BooleanType):
+			t = "boolean"
+		default:
+			continue
+		}
+		kvs = append(kvs, fmt.Sprintf("%s: %s", strconv.Quote(p.Name), strconv.Quote(t)))
+	}
+	if len(kvs) == 0 {
+		return "nil"
+	}
+	return "map[string]string{" + strings.Join(kvs, ", ") + "}"
+}
+
+// RequestValidator returns go representation of request validator entry of action
+func (a *Action) RequestValidator(id string) []byte {
+	source := "json"
+	switch {
+	case a.Method == "GET":
+		source = "query"
+	case a.Encoding == "application/x-www-form-urlencoded":
+		source = "form"
+	}
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "{\nmethod: %q,\npattern: regexp.MustCompile(`%s`),\nsource: %q,\ntypes: %s,\nvalidator: %s,\n},\n",
+		a.Method, hrefPattern(a.Href), source, paramTypes(a.Request.Properties), jsValName(id, a.Rel, "Validator"))
+	return src.Bytes()
+}
+
+// Middleware returns go middleware validating requests of actions
+func Middleware(links map[string][]Action, errRes *Resource) []byte {
+	type entry struct {
+		id     string
+		action Action
+	}
+	var ids []string
+	for id := range links {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var entries []entry
+	for _, id := range ids {
+		for _, a := range links[id] {
+			if a.Request != nil {
+				entries = append(entries, entry{id: id, action: a})
+			}
+		}
+	}
+	// static paths have to be matched before parameterized ones
+	sort.SliceStable(entries, func(i, j int) bool {
+		return len(entries[i].action.PathParams) < len(entries[j].action.PathParams)
+	})
+
+	var src bytes.Buffer
+	src.Write(importDecl(middlewareImports))
+	src.WriteString(middlewareBase)
+	src.Write(validationErrorWriter(errRes))
+	fmt.Fprint(&src, "var requestValidators = []requestValidator{\n")
+	for _, e := range entries {
+		src.Write(e.action.RequestValidator(e.id))
+	}
+	fmt.Fprint(&src, "}\n")
+	return src.Bytes()
+}
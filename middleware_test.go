@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"strings"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func TestMiddleware(t *testing.T) {
+	links := map[string][]Action{
+		"task": {
+			{
+				Encoding: "application/json",
+				Href:     "/tasks/{(#/definitions/task/definitions/identity)}",
+				Method:   "PATCH",
+				Rel:      "update",
+				PathParams: []PathParam{
+					{Name: "id", Reference: "#/definitions/task/definitions/identity"},
+				},
+				Request: &Resource{Name: "task"},
+			},
+			{
+				Encoding: "application/json",
+				Href:     "/tasks",
+				Method:   "GET",
+				Rel:      "instances",
+				Request: &Resource{
+					Name: "task",
+					Properties: []*Property{
+						&Property{Name: "limit", Types: []schema.PrimitiveType{schema.IntegerType}},
+					},
+				},
+			},
+			{
+				Encoding: "application/json",
+				Href:     "/tasks/{(#/definitions/task/definitions/identity)}",
+				Method:   "GET",
+				Rel:      "self",
+			},
+		},
+	}
+	src := append([]byte("package main\n"), Middleware(links, nil)...)
+	ss, err := format.Source(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(ss)
+	for _, expected := range []string{
+		`validator: TaskInstancesValidator`,
+		`types:     map[string]string{"limit": "integer"}`,
+		`validator: TaskUpdateValidator`,
+		`"message": err.Error()`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("%s not found in %s", expected, s)
+		}
+	}
+	if !strings.Contains(s, "err = propertyError(err)") {
+		t.Errorf("jsval error is not converted to field error: %s", s)
+	}
+	if strings.Contains(s, "TaskSelfValidator") {
+		t.Error("link without request schema must not be validated")
+	}
+	if strings.Index(s, "TaskInstancesValidator") > strings.Index(s, "TaskUpdateValidator") {
+		t.Error("static path has to be matched first")
+	}
+}
+
+func TestMiddlewareErrorResource(t *testing.T) {
+	src := append([]byte("package main\n"), Middleware(nil, testErrorResource())...)
+	ss, err := format.Source(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `// writeValidationError writes validation error with status 400
+func writeValidationError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(NewErrorFromValidation(err))
+}
+`
+	if !strings.Contains(string(ss), expected) {
+		t.Errorf("want %s got %s", expected, ss)
+	}
+}
+
+func TestMiddlewareTypeCheck(t *testing.T) {
+	generate := func(f func(fp *os.File, out *bytes.Buffer) error) []byte {
+		fp, err := os.Open("./example/doc/schema/schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fp.Close()
+		var out bytes.Buffer
+		if err := f(fp, &out); err != nil {
+			t.Fatal(err)
+		}
+		return out.Bytes()
+	}
+	pkg := "main"
+	st := generate(func(fp *os.File, out *bytes.Buffer) error {
+		return generateStructFile(&pkg, fp, out, true, false, false, OptionalNone, nil, "error")
+	})
+	mw := generate(func(fp *os.File, out *bytes.Buffer) error {
+		return generateMiddlewareFile(&pkg, fp, out, "error")
+	})
+	// validators generated by jsval command
+	validators := []byte(`package main
+
+import "github.com/lestrrat-go/go-jsval"
+
+var TaskCreateValidator, TaskInstancesValidator *jsval.JSVal
+`)
+	imports := []string{`"encoding/json"`, `"fmt"`, `"net/url"`, `"regexp"`, `"strconv"`, `"time"`,
+		`validator "gopkg.in/go-playground/validator.v9"`}
+	typeCheck(t, imports, st, mw, validators)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReadModel returns resource without writeOnly properties
+func (rs Resource) ReadModel() Resource {
+	m := rs
+	m.Properties = nil
+	for _, p := range rs.Properties {
+		if !p.WriteOnly {
+			m.Properties = append(m.Properties, p)
+		}
+	}
+	return m
+}
+
+// InputModel returns resource named <name>_<suffix> without readOnly properties.
+// every property is optional if partial, e.g. for update
+func (rs Resource) InputModel(suffix string, partial bool) Resource {
+	m := rs
+	m.Name = rs.Name + "_" + suffix
+	m.Title = ""
+	m.IsPrimary = false
+	m.Properties = nil
+	for _, p := range rs.Properties {
+		if p.ReadOnly {
+			continue
+		}
+		if partial && p.Required {
+			cp := *p
+			cp.Required = false
+			p = &cp
+		}
+		m.Properties = append(m.Properties, p)
+	}
+	return m
+}
+
+// ParseReadWriteResources parse resources as read models without writeOnly properties,
+// and create/update input models without readOnly properties
+func (p *Parser) ParseReadWriteResources() (map[string]Resource, error) {
+	resources, err := p.ParseResources()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]Resource)
+	for id, rs := range resources {
+		res[id] = rs.ReadModel()
+		for _, m := range []Resource{rs.InputModel("create_input", false), rs.InputModel("update_input", true)} {
+			if _, ok := resources[m.Name]; ok {
+				return nil, errors.Errorf("input model %s conflicts with resource", m.Name)
+			}
+			res[m.Name] = m
+		}
+	}
+	return res, nil
+}
+
+// ValidateReadOnly returns error if link request schemas refer readOnly properties
+func ValidateReadOnly(links map[string][]Action) error {
+	var msgs []string
+	for id, actions := range links {
+		for _, a := range actions {
+			if a.Request == nil {
+				continue
+			}
+			for _, name := range readOnlyProperties(a.Request.Properties, "") {
+				msgs = append(msgs, fmt.Sprintf("%s: %s %s: request refers readOnly property %s", id, a.Method, a.Href, name))
+			}
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	sort.Strings(msgs)
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+// readOnlyProperties returns paths of readOnly properties including inline objects
+func readOnlyProperties(props []*Property, prefix string) []string {
+	var names []string
+	for _, p := range props {
+		path := prefix + p.Name
+		if p.ReadOnly {
+			names = append(names, path)
+		}
+		names = append(names, readOnlyProperties(p.InlineProperties, path+".")...)
+	}
+	return names
+}
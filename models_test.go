@@ -0,0 +1,154 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func testModelResource() Resource {
+	str := []schema.PrimitiveType{schema.StringType}
+	return Resource{
+		Name:      "user",
+		IsPrimary: true,
+		Properties: []*Property{
+			&Property{Name: "id", Types: str, Required: true, ReadOnly: true},
+			&Property{Name: "name", Types: str, Required: true},
+			&Property{Name: "password", Types: str, Required: true, WriteOnly: true},
+		},
+	}
+}
+
+func propertyNames(props []*Property) []string {
+	var names []string
+	for _, p := range props {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestReadModel(t *testing.T) {
+	m := testModelResource().ReadModel()
+	if names := propertyNames(m.Properties); len(names) != 2 || names[0] != "id" || names[1] != "name" {
+		t.Errorf("unexpected properties: %v", names)
+	}
+}
+
+func TestInputModel(t *testing.T) {
+	rs := testModelResource()
+	cases := []struct {
+		Suffix   string
+		Partial  bool
+		Name     string
+		Required bool
+	}{
+		{Suffix: "create_input", Partial: false, Name: "user_create_input", Required: true},
+		{Suffix: "update_input", Partial: true, Name: "user_update_input", Required: false},
+	}
+	for _, c := range cases {
+		m := rs.InputModel(c.Suffix, c.Partial)
+		if m.Name != c.Name || m.IsPrimary {
+			t.Errorf("unexpected model: %s %t", m.Name, m.IsPrimary)
+		}
+		names := propertyNames(m.Properties)
+		if len(names) != 2 || names[0] != "name" || names[1] != "password" {
+			t.Errorf("unexpected properties: %v", names)
+		}
+		for _, p := range m.Properties {
+			if p.Required != c.Required {
+				t.Errorf("%s: want required %t got %t", p.Name, c.Required, p.Required)
+			}
+		}
+	}
+	// original properties are not modified
+	for _, p := range rs.Properties {
+		if !p.Required {
+			t.Errorf("%s: modified", p.Name)
+		}
+	}
+}
+
+func TestValidateReadOnly(t *testing.T) {
+	rs := testModelResource()
+	links := map[string][]Action{
+		"user": {
+			{Method: "POST", Href: "/users", Request: &Resource{Properties: rs.Properties[1:]}},
+		},
+	}
+	if err := ValidateReadOnly(links); err != nil {
+		t.Fatal(err)
+	}
+	links["user"] = append(links["user"], Action{
+		Method:  "PATCH",
+		Href:    "/users/{(%23%2Fdefinitions%2Fuser%2Fdefinitions%2Fidentity)}",
+		Request: &Resource{Properties: rs.Properties},
+	})
+	if err := ValidateReadOnly(links); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestParseActionsReadOnly(t *testing.T) {
+	src := `{
+  "definitions": {
+    "user": {
+      "definitions": {
+        "id": {"type": ["string"], "readOnly": true},
+        "name": {"type": ["string"], "readOnly": true},
+        "attributes": {
+          "properties": {"name": {"$ref": "#/definitions/user/definitions/name"}},
+          "type": ["object"]
+        }
+      },
+      "properties": {
+        "id": {"$ref": "#/definitions/user/definitions/id"},
+        "name": {"$ref": "#/definitions/user/definitions/name"}
+      },
+      "type": ["object"],
+      "links": [
+        {
+          "href": "/users", "method": "POST", "rel": "create",
+          "schema": {
+            "properties": {
+              "id": {"$ref": "#/definitions/user/definitions/id"},
+              "email": {"type": ["string"]},
+              "profile": {
+                "properties": {
+                  "name": {"$ref": "#/definitions/user/definitions/name"},
+                  "bio": {"type": ["string"]}
+                },
+                "type": ["object"]
+              }
+            },
+            "type": ["object"]
+          }
+        },
+        {
+          "href": "/users", "method": "PUT", "rel": "update",
+          "schema": {"allOf": [{"$ref": "#/definitions/user/definitions/attributes"}]}
+        }
+      ]
+    }
+  }
+}`
+	p, err := ReadParser(strings.NewReader(src), "model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := p.ParseResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	links, err := p.ParseActions(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ValidateReadOnly(links)
+	expected := "user: POST /users: request refers readOnly property id\n" +
+		"user: POST /users: request refers readOnly property profile.name\n" +
+		"user: PUT /users: request refers readOnly property name"
+	if err == nil || err.Error() != expected {
+		t.Errorf("want %s got %v", expected, err)
+	}
+}
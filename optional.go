@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"sort"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// Optional representation of non-required or nullable values
+type Optional string
+
+// Optional modes
+const (
+	OptionalNone    Optional = ""
+	OptionalPointer Optional = "pointer"
+	OptionalNull    Optional = "null"
+	OptionalSQLNull Optional = "sqlnull"
+	OptionalGeneric Optional = "generic"
+)
+
+// ParseOptional parses optional mode
+func ParseOptional(s string) (Optional, error) {
+	switch o := Optional(s); o {
+	case OptionalNone, OptionalPointer, OptionalNull, OptionalSQLNull, OptionalGeneric:
+		return o, nil
+	default:
+		return OptionalNone, errors.Errorf("unknown optional mode: %s", s)
+	}
+}
+
+var nullTypes = map[Optional]map[string]string{
+	OptionalNull: {
+		"float64":   "null.Float",
+		"int64":     "null.Int",
+		"bool":      "null.Bool",
+		"string":    "null.String",
+		"time.Time": "null.Time",
+	},
+	OptionalSQLNull: {
+		"float64":   "NullFloat64",
+		"int64":     "NullInt64",
+		"bool":      "NullBool",
+		"string":    "NullString",
+		"time.Time": "NullTime",
+	},
+}
+
+// sqlNullTypes value fields of database/sql null types by name. sqlnull mode wraps
+// them into types of the same name, since they are not encoded as JSON value or null
+var sqlNullTypes = map[string]string{
+	"NullFloat64": "Float64",
+	"NullInt64":   "Int64",
+	"NullBool":    "Bool",
+	"NullString":  "String",
+	"NullTime":    "Time",
+}
+
+// wrap returns go type holding optional value of t. types without null
+// representation fall back to pointer
+func (o Optional) wrap(t string) string {
+	if t == "" || o == OptionalNone {
+		return t
+	}
+	if o == OptionalGeneric {
+		return "Optional[" + t + "]"
+	}
+	if nt, ok := nullTypes[o][t]; ok {
+		return nt
+	}
+	if t[0] == '*' {
+		return t
+	}
+	return "*" + t
+}
+
+// ref returns go type holding optional struct or union value of t
+func (o Optional) ref(t string) string {
+	if o == OptionalGeneric {
+		return "Optional[" + t + "]"
+	}
+	return "*" + t
+}
+
+// nilable returns go type holding optional slice or map value of t. nil
+// represents null except generic mode
+func (o Optional) nilable(t string) string {
+	if o == OptionalGeneric {
+		return "Optional[" + t + "]"
+	}
+	return t
+}
+
+// optionalType go generic optional type used by generic mode
+const optionalType = `
+// Optional holds value which may be null or absent
+type Optional[T any] struct {
+	Value T
+	Valid bool
+}
+
+// NewOptional returns valid optional value
+func NewOptional[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Valid: true}
+}
+
+// Ptr returns pointer to value, or nil if not valid
+func (o Optional[T]) Ptr() *T {
+	if !o.Valid {
+		return nil
+	}
+	return &o.Value
+}
+
+// MarshalJSON encodes value, or null if not valid
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalJSON decodes value, null makes it not valid
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		var zero T
+		o.Value, o.Valid = zero, false
+		return nil
+	}
+	if err := json.Unmarshal(b, &o.Value); err != nil {
+		return err
+	}
+	o.Valid = true
+	return nil
+}
+`
+
+// sqlNullWrappers returns go types wrapping database/sql null types used in src
+func sqlNullWrappers(src []byte) []byte {
+	var names []string
+	for n := range sqlNullTypes {
+		if bytes.Contains(src, []byte(n)) {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Parse(`
+// {{ .Name }} sql.{{ .Name }} encoded as JSON value or null
+type {{ .Name }} struct {
+	sql.{{ .Name }}
+}
+
+// MarshalJSON encodes value, or null if not valid
+func (n {{ .Name }}) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.{{ .Field }})
+}
+
+// UnmarshalJSON decodes value, null makes it not valid
+func (n *{{ .Name }}) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		n.{{ .Name }} = sql.{{ .Name }}{}
+		return nil
+	}
+	if err := json.Unmarshal(b, &n.{{ .Field }}); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+`)
+	var out bytes.Buffer
+	for _, n := range names {
+		tmpl.Execute(&out, map[string]string{"Name": n, "Field": sqlNullTypes[n]})
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"go/format"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func TestParseOptional(t *testing.T) {
+	for _, s := range []string{"", "pointer", "null", "sqlnull", "generic"} {
+		if _, err := ParseOptional(s); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := ParseOptional("maybe"); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestScalarTypeOptional(t *testing.T) {
+	str := []schema.PrimitiveType{schema.StringType}
+	integer := []schema.PrimitiveType{schema.IntegerType}
+	cases := []struct {
+		Property Property
+		Optional Optional
+		Expected string
+	}{
+		{Property: Property{Types: str}, Optional: OptionalNone, Expected: "string"},
+		{Property: Property{Types: str}, Optional: OptionalPointer, Expected: "*string"},
+		{Property: Property{Types: str}, Optional: OptionalNull, Expected: "null.String"},
+		{Property: Property{Types: str}, Optional: OptionalSQLNull, Expected: "NullString"},
+		{Property: Property{Types: str}, Optional: OptionalGeneric, Expected: "Optional[string]"},
+		{Property: Property{Types: str, Required: true}, Optional: OptionalPointer, Expected: "string"},
+		{Property: Property{Types: integer}, Optional: OptionalSQLNull, Expected: "NullInt64"},
+		{Property: Property{Types: str, Format: "date-time"}, Optional: OptionalPointer, Expected: "*time.Time"},
+		{Property: Property{Types: str, Format: "date-time"}, Optional: OptionalSQLNull, Expected: "NullTime"},
+		{
+			Property: Property{
+				Types:       []schema.PrimitiveType{schema.ArrayType},
+				SecondTypes: integer,
+			},
+			Optional: OptionalGeneric,
+			Expected: "int64",
+		},
+		{
+			Property: Property{
+				Types:       []schema.PrimitiveType{schema.ArrayType},
+				SecondTypes: []schema.PrimitiveType{schema.IntegerType, schema.NullType},
+				Required:    true,
+			},
+			Optional: OptionalNull,
+			Expected: "null.Int",
+		},
+		{
+			Property: Property{Types: []schema.PrimitiveType{schema.BooleanType, schema.NullType}, Required: true},
+			Optional: OptionalNull,
+			Expected: "null.Bool",
+		},
+		{
+			Property: Property{Types: str, Enum: &Enum{Name: "task_status", Types: str}},
+			Optional: OptionalPointer,
+			Expected: "*TaskStatus",
+		},
+	}
+	for _, c := range cases {
+		if s := c.Property.ScalarType(FormatOption{Optional: c.Optional}); s != c.Expected {
+			t.Errorf("%s: want %s got %s", c.Optional, c.Expected, s)
+		}
+	}
+}
+
+func TestGoTypeOptional(t *testing.T) {
+	boolean := []schema.PrimitiveType{schema.BooleanType}
+	nullBool := []schema.PrimitiveType{schema.BooleanType, schema.NullType}
+	array := []schema.PrimitiveType{schema.ArrayType}
+	nullArray := []schema.PrimitiveType{schema.ArrayType, schema.NullType}
+	object := []schema.PrimitiveType{schema.ObjectType}
+	nullObject := []schema.PrimitiveType{schema.ObjectType, schema.NullType}
+	union := &Union{Name: "task_target"}
+	cases := []struct {
+		Property Property
+		Optional Optional
+		Expected string
+	}{
+		// scalar
+		{Property: Property{PropType: PropTypeScalar, Types: nullBool, Required: true}, Optional: OptionalNull, Expected: "null.Bool"},
+		{Property: Property{PropType: PropTypeScalar, Types: boolean}, Optional: OptionalNull, Expected: "null.Bool"},
+		{Property: Property{PropType: PropTypeScalar, Types: boolean, Required: true}, Optional: OptionalNull, Expected: "bool"},
+		{Property: Property{PropType: PropTypeScalar, Types: nullBool, Required: true}, Optional: OptionalPointer, Expected: "*bool"},
+		// array
+		{Property: Property{PropType: PropTypeArray, Types: nullArray, SecondTypes: boolean, Required: true}, Optional: OptionalNull, Expected: "[]bool"},
+		{Property: Property{PropType: PropTypeArray, Types: nullArray, SecondTypes: nullBool, Required: true}, Optional: OptionalNull, Expected: "[]null.Bool"},
+		{Property: Property{PropType: PropTypeArray, Types: array, SecondTypes: boolean}, Optional: OptionalGeneric, Expected: "Optional[[]bool]"},
+		{Property: Property{PropType: PropTypeArray, Types: array, SecondTypes: boolean, Required: true}, Optional: OptionalGeneric, Expected: "[]bool"},
+		// object reference
+		{Property: Property{PropType: PropTypeObject, Types: object, Reference: "#/definitions/user", Required: true}, Optional: OptionalNone, Expected: "*User"},
+		{Property: Property{PropType: PropTypeObject, Types: object, Reference: "#/definitions/user", Required: true}, Optional: OptionalNull, Expected: "User"},
+		{Property: Property{PropType: PropTypeObject, Types: nullObject, Reference: "#/definitions/user", Required: true}, Optional: OptionalNull, Expected: "*User"},
+		{Property: Property{PropType: PropTypeObject, Types: object, Reference: "#/definitions/user"}, Optional: OptionalGeneric, Expected: "Optional[User]"},
+		// union
+		{Property: Property{PropType: PropTypeUnion, Union: union, Required: true}, Optional: OptionalPointer, Expected: "TaskTarget"},
+		{Property: Property{PropType: PropTypeUnion, Union: union}, Optional: OptionalPointer, Expected: "*TaskTarget"},
+		{Property: Property{PropType: PropTypeUnion, Union: union}, Optional: OptionalGeneric, Expected: "Optional[TaskTarget]"},
+	}
+	for _, c := range cases {
+		if s := c.Property.GoType(FormatOption{Optional: c.Optional}); s != c.Expected {
+			t.Errorf("%s: want %s got %s", c.Optional, c.Expected, s)
+		}
+	}
+}
+
+// runGo runs go program src and returns its output. test is skipped if go
+// command is not available
+func runGo(t *testing.T, src []byte) string {
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	ss, err := format.Source(src)
+	if err != nil {
+		t.Fatalf("%s: %s", err, src)
+	}
+	dir, err := ioutil.TempDir("", "prmdg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(file, ss, 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(gobin, "run", file).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %s\n%s", err, out, ss)
+	}
+	return string(out)
+}
+
+func TestSQLNullRoundTrip(t *testing.T) {
+	res := Resource{
+		Name: "task",
+		Properties: []*Property{
+			{Name: "title", Types: []schema.PrimitiveType{schema.StringType}},
+			{Name: "spent", Types: []schema.PrimitiveType{schema.IntegerType, schema.NullType}, Required: true},
+		},
+	}
+	op := FormatOption{Optional: OptionalSQLNull}
+	src := []byte(`package main
+
+	import (
+		"database/sql"
+		"encoding/json"
+		"fmt"
+	)
+	`)
+	src = append(src, res.Struct(op)...)
+	src = append(src, sqlNullWrappers(src)...)
+	src = append(src, []byte(`
+	func main() {
+		for _, in := range []string{
+			"{\"title\": \"Buy coffee\", \"spent\": 10}",
+			"{\"title\": null, \"spent\": null}",
+			"{}",
+		} {
+			var task Task
+			if err := json.Unmarshal([]byte(in), &task); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			out, err := json.Marshal(task)
+			fmt.Println(string(out), err, task.Title.Valid, task.Spent.Valid)
+		}
+	}
+	`)...)
+	expected := `{"title":"Buy coffee","spent":10} <nil> true true
+{"title":null,"spent":null} <nil> false false
+{"title":null,"spent":null} <nil> false false
+`
+	if out := runGo(t, src); out != expected {
+		t.Errorf("want %s got %s", expected, out)
+	}
+}
+
+func TestSQLNullWrappers(t *testing.T) {
+	// formatted as fragment like struct command, so that indentation is kept
+	ss, err := format.Source(sqlNullWrappers([]byte("Done NullBool `json:\"done\"`")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+// NullBool sql.NullBool encoded as JSON value or null
+type NullBool struct {
+	sql.NullBool
+}
+
+// MarshalJSON encodes value, or null if not valid
+func (n NullBool) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Bool)
+}
+
+// UnmarshalJSON decodes value, null makes it not valid
+func (n *NullBool) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		n.NullBool = sql.NullBool{}
+		return nil
+	}
+	if err := json.Unmarshal(b, &n.Bool); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+`
+	if string(ss) != expected {
+		t.Errorf("want %s got %s", expected, ss)
+	}
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"io"
+	"io/ioutil"
 	"net/url"
+	"os"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/achiku/varfmt"
@@ -21,12 +26,15 @@ const (
 	PropTypeScalar PropType = iota
 	PropTypeArray
 	PropTypeObject
+	PropTypeUnion
 )
 
 // Parser convertor
 type Parser struct {
 	schema  *schema.Schema
 	pkgName string
+	file    string
+	source  []byte
 }
 
 // NewParser creates parser
@@ -37,16 +45,217 @@ func NewParser(sh *schema.Schema, pkgName string) *Parser {
 	}
 }
 
-func resolveSchema(sch *schema.Schema, root *schema.Schema) (*schema.Schema, error) {
-	if sch.IsResolved() {
-		return sch, nil
+// ReadParser reads schema and creates parser. errors of parser are located by
+// line and column if schema is read from file
+func ReadParser(fp io.Reader, pkgName string) (*Parser, error) {
+	src, err := ioutil.ReadAll(fp)
+	if err != nil {
+		return nil, err
 	}
-	sh, err := sch.Resolve(root)
+	sc, err := schema.Read(bytes.NewReader(src))
 	if err != nil {
 		return nil, err
 	}
-	// FIXME: recursively resolving schema. may need to limit # of recursion.
-	return resolveSchema(sh, root)
+	p := NewParser(sc, pkgName)
+	if f, ok := fp.(*os.File); ok {
+		p.file = f.Name()
+		p.source = src
+	}
+	return p, nil
+}
+
+// errorOf returns collected errors located in source, or nil if no error
+func (p *Parser) errorOf(errs ErrorList) error {
+	if p.source != nil {
+		errs.locate(p.file, p.source)
+	}
+	return errs.err()
+}
+
+// resolveSchema resolves references until schema is resolved. returns error if
+// references refer each other without any schema
+func resolveSchema(sch *schema.Schema, root *schema.Schema) (*schema.Schema, error) {
+	var refs []string
+	for !sch.IsResolved() {
+		for _, r := range refs {
+			if r == sch.Reference {
+				return nil, errors.Errorf("circular reference: %s", strings.Join(append(refs, r), " -> "))
+			}
+		}
+		refs = append(refs, sch.Reference)
+		sh, err := sch.Resolve(root)
+		if err != nil {
+			return nil, err
+		}
+		sch = sh
+	}
+	return sch, nil
+}
+
+// expansion references being expanded into inline properties, and properties
+// expanding them. used to detect recursive types
+type expansion map[string]*Property
+
+// recur marks property referring ref being expanded, and property expanding it,
+// as recursive type named by ref. main resources are already named
+func (exp expansion) recur(ref string, pr *Property) error {
+	if isMainResource(ref) {
+		return nil
+	}
+	name := refToEnumName(ref)
+	if name == "" {
+		return errors.Errorf("recursive reference can't be named: %s", ref)
+	}
+	exp[ref].RecursiveType = name
+	pr.RecursiveType = name
+	return nil
+}
+
+// mergeAllOf returns schema merging properties and required of allOf members,
+// and references to main resources in allOf which are embedded instead of merged
+func mergeAllOf(sc *schema.Schema, root *schema.Schema) (*schema.Schema, []string, error) {
+	return mergeAllOfMembers(sc, root, make(map[string]bool))
+}
+
+// mergeAllOfMembers merges allOf members. refs holds references of members being
+// merged to detect members including themselves
+func mergeAllOfMembers(sc *schema.Schema, root *schema.Schema, refs map[string]bool) (*schema.Schema, []string, error) {
+	if len(sc.AllOf) == 0 {
+		return sc, nil, nil
+	}
+	merged := schema.New()
+	merged.ID = sc.ID
+	merged.Title = sc.Title
+	merged.Description = sc.Description
+	merged.Type = sc.Type
+	merged.Extras = sc.Extras
+	merged.Properties = make(map[string]*schema.Schema)
+	for name, prop := range sc.Properties {
+		merged.Properties[name] = prop
+	}
+	merged.Required = append(merged.Required, sc.Required...)
+
+	var embeds []string
+	for i, member := range sc.AllOf {
+		if isMainResource(member.Reference) {
+			embeds = append(embeds, member.Reference)
+			continue
+		}
+		if refs[member.Reference] {
+			return nil, nil, errors.Errorf("allOf member %d includes itself: %s", i, member.Reference)
+		}
+		rs, err := resolveSchema(member, root)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to resolve allOf member %d", i)
+		}
+		if member.Reference != "" {
+			refs[member.Reference] = true
+		}
+		rs, nested, err := mergeAllOfMembers(rs, root, refs)
+		delete(refs, member.Reference)
+		if err != nil {
+			return nil, nil, err
+		}
+		embeds = append(embeds, nested...)
+		for name, prop := range rs.Properties {
+			ex, ok := merged.Properties[name]
+			if !ok {
+				merged.Properties[name] = prop
+				continue
+			}
+			if err := checkCompatible(name, ex, prop, root); err != nil {
+				return nil, nil, err
+			}
+		}
+		for _, r := range rs.Required {
+			if !merged.IsPropRequired(r) {
+				merged.Required = append(merged.Required, r)
+			}
+		}
+		if len(merged.Type) == 0 {
+			merged.Type = rs.Type
+		}
+	}
+	if len(merged.Type) == 0 {
+		merged.Type = schema.PrimitiveTypes{schema.ObjectType}
+	}
+	return merged, embeds, nil
+}
+
+// checkCompatible returns error if the same property has incompatible types,
+// including properties and items of nested objects and arrays
+func checkCompatible(name string, a, b *schema.Schema, root *schema.Schema) error {
+	return compatible(name, a, b, root, make(map[[2]*schema.Schema]bool))
+}
+
+// compatible compares a and b recursively. seen holds pairs of resolved schemas
+// already compared, so that recursive schemas are compared once
+func compatible(name string, a, b *schema.Schema, root *schema.Schema, seen map[[2]*schema.Schema]bool) error {
+	if a.Reference != "" && a.Reference == b.Reference {
+		return nil
+	}
+	ra, err := resolveSchema(a, root)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve %s", name)
+	}
+	rb, err := resolveSchema(b, root)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve %s", name)
+	}
+	if ra == rb || seen[[2]*schema.Schema{ra, rb}] {
+		return nil
+	}
+	seen[[2]*schema.Schema{ra, rb}] = true
+	ta, tb := typesToStrings(ra.Type), typesToStrings(rb.Type)
+	sort.Strings(ta)
+	sort.Strings(tb)
+	if strings.Join(ta, ",") != strings.Join(tb, ",") || ra.Format != rb.Format {
+		return errors.Errorf(
+			"conflicting types in allOf for %s: %s(%s) and %s(%s)",
+			name, strings.Join(ta, ","), ra.Format, strings.Join(tb, ","), rb.Format)
+	}
+
+	pa, pb := schemaPropertyNames(ra), schemaPropertyNames(rb)
+	if strings.Join(pa, ",") != strings.Join(pb, ",") {
+		return errors.Errorf(
+			"conflicting properties in allOf for %s: [%s] and [%s]",
+			name, strings.Join(pa, ", "), strings.Join(pb, ", "))
+	}
+	for _, n := range pa {
+		if err := compatible(name+"."+n, ra.Properties[n], rb.Properties[n], root, seen); err != nil {
+			return err
+		}
+	}
+
+	ia, ib := itemSchema(ra), itemSchema(rb)
+	switch {
+	case ia == nil && ib == nil:
+	case ia == nil || ib == nil:
+		return errors.Errorf("conflicting items in allOf for %s", name)
+	default:
+		if err := compatible(name+"[]", ia, ib, root, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaPropertyNames returns sorted property names of schema
+func schemaPropertyNames(sc *schema.Schema) []string {
+	var names []string
+	for n := range sc.Properties {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// itemSchema returns the only item schema of array schema, or nil
+func itemSchema(sc *schema.Schema) *schema.Schema {
+	if sc.Items == nil || sc.Items.TupleMode || len(sc.Items.Schemas) != 1 {
+		return nil
+	}
+	return sc.Items.Schemas[0]
 }
 
 func typesToStrings(types schema.PrimitiveTypes) []string {
@@ -109,13 +318,47 @@ func sortValidator(vals []*jsval.JSVal) []*jsval.JSVal {
 	return sorted
 }
 
+// setDoc sets description, example, readOnly, writeOnly and default of property.
+// keywords next to $ref take precedence over the ones of referenced schema
+func (pr *Property) setDoc(schemas ...*schema.Schema) {
+	for i := len(schemas) - 1; i >= 0; i-- {
+		sc := schemas[i]
+		if sc.Description != "" {
+			pr.Description = sc.Description
+		}
+		if v, ok := sc.Extras["example"]; ok {
+			pr.Example = v
+		}
+		if v, ok := sc.Extras["readOnly"].(bool); ok {
+			pr.ReadOnly = v
+		}
+		if v, ok := sc.Extras["writeOnly"].(bool); ok {
+			pr.WriteOnly = v
+		}
+		if sc.Default != nil {
+			pr.Default = sc.Default
+		}
+	}
+}
+
 // NewProperty new property
 func NewProperty(name string, tp *schema.Schema, df *schema.Schema, root *schema.Schema) (*Property, error) {
+	return newProperty(name, tp, df, root, make(expansion))
+}
+
+// newProperty creates property. references in exp are not expanded again, and
+// referred as recursive type instead
+func newProperty(name string, tp *schema.Schema, df *schema.Schema, root *schema.Schema, exp expansion) (*Property, error) {
 	// save reference before resolving ref
 	ref := tp.Reference
 	fieldSchema, err := resolveSchema(tp, root)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to resolve, %s", name)
+		return nil, errors.Wrap(err, "failed to resolve")
+	}
+	hasAllOf := len(fieldSchema.AllOf) != 0
+	fieldSchema, embeds, err := mergeAllOf(fieldSchema, root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to merge allOf")
 	}
 	fld := &Property{
 		Name:      name,
@@ -125,20 +368,50 @@ func NewProperty(name string, tp *schema.Schema, df *schema.Schema, root *schema
 		Pattern:   fieldSchema.Pattern,
 		Reference: ref,
 		Schema:    fieldSchema,
+		Embeds:    embeds,
+	}
+	if fld.Enum, err = NewEnum(refToEnumName(ref), fieldSchema); err != nil {
+		return nil, err
+	}
+	fld.setDoc(tp, fieldSchema)
+	if fld.GoTypeOverride, err = goTypeOverride(tp, fieldSchema); err != nil {
+		return nil, errors.Wrap(err, "failed to parse x-go-type")
+	}
+	if fld.GoTypeOverride != nil {
+		// overridden type replaces generated enum type
+		fld.Enum = nil
 	}
 	switch {
+	case len(fieldSchema.OneOf) != 0 || len(fieldSchema.AnyOf) != 0:
+		// if this field is one of multiple schemas
+		fld.Union, err = NewUnion(refToEnumName(ref), fieldSchema, root, exp)
+		if err != nil {
+			return nil, err
+		}
+		fld.PropType = PropTypeUnion
 	case fieldSchema.Type.Contains(schema.ArrayType):
 		// if this field is an array
 		// currently this tool supports only one itme per array field
-		if len(fieldSchema.Items.Schemas) != 1 {
-			return nil, errors.Errorf("array type has to have an item: %s", name)
+		if fieldSchema.Items == nil || len(fieldSchema.Items.Schemas) != 1 {
+			return nil, errors.New("array type has to have an item")
 		}
 		item := fieldSchema.Items.Schemas[0]
 		resolvedItem, err := resolveSchema(item, root)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to resolve: %s", name)
+			var errs ErrorList
+			errs.add(errors.Wrap(err, "failed to resolve"), "items")
+			return nil, errs
 		}
+		fld.ItemSchema = resolvedItem
 		switch {
+		case len(resolvedItem.OneOf) != 0 || len(resolvedItem.AnyOf) != 0:
+			// an array of union type
+			fld.Union, err = NewUnion(refToEnumName(item.Reference), resolvedItem, root, exp)
+			if err != nil {
+				var errs ErrorList
+				errs.add(err, "items")
+				return nil, errs
+			}
 		case isMainResource(item.Reference) && resolvedItem.Type.Contains(schema.ObjectType):
 			// reference to main resource object
 			// log.Printf("ref to main resource: %s: %s", name, item.Reference)
@@ -147,67 +420,146 @@ func NewProperty(name string, tp *schema.Schema, df *schema.Schema, root *schema
 		case item.Properties == nil && fieldSchema.Properties != nil:
 			// field schema already has properties = inline object
 			// log.Printf("inline obj: %s: %v", name, fieldSchema.Properties)
-			var inlineFields []*Property
+			var (
+				inlineFields []*Property
+				errs         ErrorList
+			)
 			for k, prop := range fieldSchema.Properties {
-				f, err := NewProperty(k, prop, df, root)
+				f, err := newProperty(k, prop, df, root, exp)
 				if err != nil {
-					return nil, errors.Wrapf(err, "failed to perse inline object: %s", k)
+					errs.add(err, "properties", k)
+					continue
 				}
 				inlineFields = append(inlineFields, f)
 			}
+			if err := errs.err(); err != nil {
+				return nil, err
+			}
 			fld.InlineProperties = inlineFields
 			fld.SecondTypes = []schema.PrimitiveType{schema.ObjectType}
 		case item.Reference == "" && item.Properties == nil:
 			// no reference, no item properties = primitive type
 			// log.Printf("primitive type: %s %s", name, item.Type)
 			fld.SecondTypes = item.Type
+			fld.SecondFormat = string(item.Format)
+			if fld.Enum, err = NewEnum("", item); err != nil {
+				var errs ErrorList
+				errs.add(err, "items")
+				return nil, errs
+			}
 		case item.Reference != "" && !resolvedItem.Type.Contains(schema.ObjectType):
 			// reference to primitive = resolved primitive type
 			// log.Printf("resolved primitive type: %s %s", name, resolvedItem.Type)
 			fld.SecondTypes = resolvedItem.Type
+			fld.SecondFormat = string(resolvedItem.Format)
+			if fld.Enum, err = NewEnum(refToEnumName(item.Reference), resolvedItem); err != nil {
+				var errs ErrorList
+				errs.add(err, "items")
+				return nil, errs
+			}
 		case item.Reference == "" && item.Properties != nil:
 			// no reference, item properties = inline object
 			// parse properties, and recursively create inline fields
 			// log.Printf("resolved inline obj: %s: %v", name, item.Properties)
-			var inlineFields []*Property
+			var (
+				inlineFields []*Property
+				errs         ErrorList
+			)
 			for k, prop := range item.Properties {
-				f, err := NewProperty(k, prop, df, root)
+				f, err := newProperty(k, prop, df, root, exp)
 				if err != nil {
-					return nil, errors.Wrapf(err, "failed to perse inline object: %s", k)
+					errs.add(err, "items", "properties", k)
+					continue
 				}
 				inlineFields = append(inlineFields, f)
 			}
+			if err := errs.err(); err != nil {
+				return nil, err
+			}
 			fld.InlineProperties = inlineFields
 			fld.SecondTypes = []schema.PrimitiveType{schema.ObjectType}
 		case !isMainResource(item.Reference):
 			// log.Printf("resolved inline obj: %s: %v", name, resolvedItem.Properties)
-			var inlineFields []*Property
+			fld.SecondTypes = []schema.PrimitiveType{schema.ObjectType}
+			if _, ok := exp[item.Reference]; ok {
+				// recursive reference is not expanded again
+				if err := exp.recur(item.Reference, fld); err != nil {
+					return nil, err
+				}
+				break
+			}
+			exp[item.Reference] = fld
+			defer delete(exp, item.Reference)
+			var (
+				inlineFields []*Property
+				errs         ErrorList
+			)
 			for k, prop := range resolvedItem.Properties {
-				f, err := NewProperty(k, prop, df, root)
+				f, err := newProperty(k, prop, df, root, exp)
 				if err != nil {
-					return nil, errors.Wrapf(err, "failed to perse inline object: %s", k)
+					errs.add(err, "items", "properties", k)
+					continue
 				}
 				inlineFields = append(inlineFields, f)
 			}
+			if err := errs.err(); err != nil {
+				return nil, err
+			}
 			fld.InlineProperties = inlineFields
-			fld.SecondTypes = []schema.PrimitiveType{schema.ObjectType}
+		}
+		if len(fld.InlineProperties) != 0 && !isMainResource(item.Reference) {
+			fld.AdditionalProperties, err = newAdditionalProperty(name, resolvedItem, root, exp)
+			if err != nil {
+				var errs ErrorList
+				errs.add(err, "items")
+				return nil, errs
+			}
 		}
 		fld.PropType = PropTypeArray
 	case fieldSchema.Type.Contains(schema.ObjectType):
 		// if this field is a object
 		switch {
 		case fieldSchema.Reference == "" && fieldSchema.Properties != nil:
+			if _, ok := exp[ref]; ok && ref != "" {
+				// recursive reference is not expanded again
+				if err := exp.recur(ref, fld); err != nil {
+					return nil, err
+				}
+				break
+			}
+			if ref != "" {
+				exp[ref] = fld
+				defer delete(exp, ref)
+			}
 			// inline object without definitions
-			var inlineFields []*Property
+			// properties merged from allOf are required by merged schema
+			rq := df
+			if hasAllOf {
+				rq = fieldSchema
+			}
+			var (
+				inlineFields []*Property
+				errs         ErrorList
+			)
 			for k, prop := range fieldSchema.Properties {
-				f, err := NewProperty(k, prop, df, root)
+				f, err := newProperty(k, prop, rq, root, exp)
 				if err != nil {
-					return nil, errors.Wrapf(err, "failed to perse inline object: %s", k)
+					errs.add(err, "properties", k)
+					continue
 				}
 				inlineFields = append(inlineFields, f)
 			}
+			if err := errs.err(); err != nil {
+				return nil, err
+			}
 			fld.InlineProperties = inlineFields
 		}
+		if !isMainResource(ref) {
+			fld.AdditionalProperties, err = newAdditionalProperty(name, fieldSchema, root, exp)
+			if err != nil {
+				return nil, err
+			}
+		}
 		fld.PropType = PropTypeObject
 	default:
 		// if this field is a scalar
@@ -218,20 +570,40 @@ func NewProperty(name string, tp *schema.Schema, df *schema.Schema, root *schema
 
 // ParseValidators parse validator
 func (p *Parser) ParseValidators() (Validators, error) {
+	res, err := p.ParseResources()
+	if err != nil {
+		return nil, err
+	}
+	links, err := p.ParseActions(res)
+	if err != nil {
+		return nil, err
+	}
 	vals := make(Validators)
-	for _, df := range p.schema.Definitions {
-		for name, tp := range df.Properties {
-			fs, err := resolveSchema(tp, p.schema)
-			if err != nil {
-				return nil, err
-			}
-			if fs.Pattern != nil &&
-				!fs.Type.Contains(schema.ObjectType) && !fs.Type.Contains(schema.ArrayType) {
-				v := Validator{
-					Name:         name,
-					RegexpString: fs.Pattern.String(),
+	// iterate in order so that errors are reported deterministically
+	var ids []string
+	for id := range res {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := vals.collect(res[id].Properties); err != nil {
+			return nil, err
+		}
+	}
+	var linkIDs []string
+	for id := range links {
+		linkIDs = append(linkIDs, id)
+	}
+	sort.Strings(linkIDs)
+	for _, id := range linkIDs {
+		for _, a := range links[id] {
+			for _, r := range []*Resource{a.Request, a.Response} {
+				if r == nil {
+					continue
+				}
+				if err := vals.collect(r.Properties); err != nil {
+					return nil, err
 				}
-				vals[name] = v
 			}
 		}
 	}
@@ -241,45 +613,71 @@ func (p *Parser) ParseValidators() (Validators, error) {
 // ParseResources parse plain resource
 func (p *Parser) ParseResources() (map[string]Resource, error) {
 	res := make(map[string]Resource)
+	var errs ErrorList
 	// parse resource itself
 	for id, df := range p.schema.Definitions {
 		rs := Resource{
-			Name:      id,
-			Title:     df.Title,
-			Schema:    df,
-			IsPrimary: true,
+			Name:        id,
+			Title:       df.Title,
+			Description: df.Description,
+			Schema:      df,
+			IsPrimary:   true,
+		}
+		merged, embeds, err := mergeAllOf(df, p.schema)
+		if err != nil {
+			errs.add(errors.Wrap(err, "failed to merge allOf"), "definitions", id)
+			continue
 		}
 		// parse resource field
 		var flds []*Property
-		for name, tp := range df.Properties {
-			fld, err := NewProperty(name, tp, df, p.schema)
+		for name, tp := range merged.Properties {
+			fld, err := NewProperty(name, tp, merged, p.schema)
 			if err != nil {
-				return nil, errors.Wrapf(err, "failed to parse %s", id)
+				errs.add(err, "definitions", id, "properties", name)
+				continue
 			}
 			fld.InlineProperties = sortProperties(fld.InlineProperties)
 			flds = append(flds, fld)
 		}
+		rs.AdditionalProperties, err = newAdditionalProperty("additionalProperties", merged, p.schema, make(expansion))
+		if err != nil {
+			errs.add(err, "definitions", id)
+			continue
+		}
+		if rs.AdditionalProperties != nil {
+			nameTypes([]*Property{rs.AdditionalProperties}, id)
+		}
+		nameTypes(flds, id)
+		nameValidators(flds, id)
 		rs.Properties = sortProperties(flds)
+		rs.Embeds = embeds
 		res[id] = rs
 	}
+	if err := p.errorOf(errs); err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
 // ParseActions parse endpoints
 func (p *Parser) ParseActions(res map[string]Resource) (map[string][]Action, error) {
 	eptsMap := make(map[string][]Action)
+	var errs ErrorList
 	for id, df := range p.schema.Definitions {
 		// use json hyper schema to parse links
 		hsc := hschema.New()
 		if err := hsc.Extract(df.Extras); err != nil {
-			return nil, errors.Wrapf(err, "failed to extract links for (%s)", id)
+			errs.add(errors.Wrap(err, "failed to extract links"), "definitions", id, "links")
+			continue
 		}
 		// parse endpoints
 		var eps []Action
-		for _, e := range hsc.Links {
+		for i, e := range hsc.Links {
+			ptr := []string{"definitions", id, "links", strconv.Itoa(i)}
 			href, err := url.QueryUnescape(e.Href)
 			if err != nil {
-				return nil, errors.Wrapf(err, "failed to unescape %s", e.Href)
+				errs.add(errors.Wrapf(err, "failed to unescape %s", e.Href), append(ptr, "href")...)
+				continue
 			}
 			var encoding string
 			if e.EncType == "" {
@@ -287,49 +685,111 @@ func (p *Parser) ParseActions(res map[string]Resource) (map[string][]Action, err
 			} else {
 				encoding = e.EncType
 			}
+			params, err := p.parsePathParams(href)
+			if err != nil {
+				errs.add(errors.Wrap(err, "failed to parse path parameters"), append(ptr, "href")...)
+				continue
+			}
 			ep := Action{
-				Encoding: encoding,
-				Href:     href,
-				Method:   e.Method,
-				Title:    e.Title,
-				Rel:      e.Rel,
+				Encoding:   encoding,
+				Href:       href,
+				Method:     e.Method,
+				Title:      e.Title,
+				Rel:        e.Rel,
+				PathParams: params,
 			}
 			// parse request if exists
 			if e.Schema != nil {
-				var flds []*Property
-				for name, tp := range e.Schema.Properties {
-					fld, err := NewProperty(name, tp, e.Schema, p.schema)
+				sptr := append(ptr, "schema")
+				sc, embeds, err := mergeAllOf(e.Schema, p.schema)
+				if err != nil {
+					errs.add(errors.Wrap(err, "failed to merge allOf"), sptr...)
+					continue
+				}
+				var (
+					flds   []*Property
+					failed bool
+				)
+				for name, tp := range sc.Properties {
+					fld, err := NewProperty(name, tp, sc, p.schema)
 					if err != nil {
-						return nil, errors.Wrapf(err, "failed to parse %s", id)
+						errs.add(err, append(sptr, "properties", name)...)
+						failed = true
+						continue
 					}
 					flds = append(flds, fld)
 				}
+				ap, err := newAdditionalProperty("additionalProperties", sc, p.schema, make(expansion))
+				if err != nil {
+					errs.add(err, sptr...)
+					continue
+				}
+				if failed {
+					continue
+				}
+				if ap != nil {
+					nameTypes([]*Property{ap}, id+"_"+e.Rel)
+				}
+				nameTypes(flds, id+"_"+e.Rel)
+				nameValidators(flds, id)
 				ep.Request = &Resource{
-					Name:       id,
-					Properties: sortProperties(flds),
-					Title:      e.Schema.Title,
-					IsPrimary:  false,
+					Name:                 id,
+					Properties:           sortProperties(flds),
+					Embeds:               embeds,
+					AdditionalProperties: ap,
+					Title:                e.Schema.Title,
+					IsPrimary:            false,
 				}
 			}
 			// parse response if exists
 			if e.TargetSchema != nil {
+				tptr := append(ptr, "targetSchema")
 				// http://json-schema.org/latest/json-schema-hypermedia.html#rfc.section.5.4
 				switch {
 				case e.TargetSchema.Reference == "":
-					var flds []*Property
-					for name, tp := range e.TargetSchema.Properties {
-						fld, err := NewProperty(name, tp, df, p.schema)
+					sc, embeds, err := mergeAllOf(e.TargetSchema, p.schema)
+					if err != nil {
+						errs.add(errors.Wrap(err, "failed to merge allOf"), tptr...)
+						continue
+					}
+					rq := df
+					if len(e.TargetSchema.AllOf) != 0 {
+						rq = sc
+					}
+					var (
+						flds   []*Property
+						failed bool
+					)
+					for name, tp := range sc.Properties {
+						fld, err := NewProperty(name, tp, rq, p.schema)
 						if err != nil {
-							return nil, errors.Wrapf(err, "failed to parse %s", id)
+							errs.add(err, append(tptr, "properties", name)...)
+							failed = true
+							continue
 						}
 						flds = append(flds, fld)
 					}
+					ap, err := newAdditionalProperty("additionalProperties", sc, p.schema, make(expansion))
+					if err != nil {
+						errs.add(err, tptr...)
+						continue
+					}
+					if failed {
+						continue
+					}
+					if ap != nil {
+						nameTypes([]*Property{ap}, id+"_"+e.Rel)
+					}
+					nameTypes(flds, id+"_"+e.Rel)
+					nameValidators(flds, id)
 					ep.Response = &Resource{
-						Name:       id,
-						Properties: sortProperties(flds),
-						Title:      e.TargetSchema.Title,
-						Schema:     e.TargetSchema,
-						IsPrimary:  false,
+						Name:                 id,
+						Properties:           sortProperties(flds),
+						Embeds:               embeds,
+						AdditionalProperties: ap,
+						Title:                e.TargetSchema.Title,
+						Schema:               e.TargetSchema,
+						IsPrimary:            false,
 					}
 				case e.TargetSchema.Reference != "" && IsRefToMainResource(e.TargetSchema.Reference):
 					ep.Response = &Resource{
@@ -341,8 +801,11 @@ func (p *Parser) ParseActions(res map[string]Resource) (map[string][]Action, err
 				case e.TargetSchema.Reference != "" && !IsRefToMainResource(e.TargetSchema.Reference):
 					fld, err := NewProperty(e.TargetSchema.ID, e.TargetSchema, df, p.schema)
 					if err != nil {
-						return nil, errors.Wrapf(err, "failed to parse %s", id)
+						errs.add(err, tptr...)
+						continue
 					}
+					nameTypes(fld.InlineProperties, id+"_"+e.Rel)
+					nameValidators(fld.InlineProperties, id)
 					ep.Response = &Resource{
 						Name:       id,
 						Properties: sortProperties(fld.InlineProperties),
@@ -355,7 +818,8 @@ func (p *Parser) ParseActions(res map[string]Resource) (map[string][]Action, err
 				// if targetSchema is not set, use default resource for this link
 				resp, ok := res[id]
 				if !ok {
-					return nil, errors.Errorf("resource not found: %s", id)
+					errs.add(errors.Errorf("resource not found: %s", id), ptr...)
+					continue
 				}
 				ep.Response = &resp
 			}
@@ -363,43 +827,133 @@ func (p *Parser) ParseActions(res map[string]Resource) (map[string][]Action, err
 		}
 		eptsMap[id] = sortActions(eps)
 	}
+	if err := p.errorOf(errs); err != nil {
+		return nil, err
+	}
 	return eptsMap, nil
 }
 
-// ParseJsValValidators parse validator
-func (p *Parser) ParseJsValValidators() ([]*jsval.JSVal, error) {
-	var validators []*jsval.JSVal
+// parsePathParams parse path parameters in href template
+func (p *Parser) parsePathParams(href string) ([]PathParam, error) {
+	var params []PathParam
+	names := make(map[string]int)
+	for _, m := range hrefParamRe.FindAllStringSubmatch(href, -1) {
+		ref := m[1]
+		sc := schema.New()
+		sc.Reference = ref
+		// resolve only one level to use referred definition name, e.g. identity -> id
+		df, err := sc.Resolve(p.schema)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %s", ref)
+		}
+		name := ref
+		if df.Reference != "" {
+			name = df.Reference
+		}
+		name = name[strings.LastIndex(name, "/")+1:]
+		rs, err := resolveSchema(df, p.schema)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve %s", ref)
+		}
+		params = append(params, PathParam{
+			Name:      name,
+			Reference: ref,
+			Types:     rs.Type,
+			Format:    string(rs.Format),
+			Pattern:   rs.Pattern,
+		})
+		names[name]++
+	}
+	// prefix resource name if the same name appears more than once
+	for i, pp := range params {
+		if names[pp.Name] > 1 {
+			res := strings.SplitN(strings.Replace(pp.Reference, "#/definitions/", "", 1), "/", 2)[0]
+			params[i].Name = res + "_" + pp.Name
+		}
+	}
+	return params, nil
+}
+
+// ParseJsValValidators parse validator. response validators are also parsed
+// from targetSchema, or main resource if targetSchema is not set, if withResponse
+func (p *Parser) ParseJsValValidators(withResponse bool) ([]*jsval.JSVal, error) {
+	var (
+		validators []*jsval.JSVal
+		errs       ErrorList
+	)
 	for id, df := range p.schema.Definitions {
 		// use json hyper schema to parse links
 		hsc := hschema.New()
 		if err := hsc.Extract(df.Extras); err != nil {
-			return nil, errors.Wrapf(err, "failed to extract links for (%s)", id)
+			errs.add(errors.Wrap(err, "failed to extract links"), "definitions", id, "links")
+			continue
 		}
 
-		for _, e := range hsc.Links {
-			var v *jsval.JSVal
-			if e.Schema == nil {
-				v = jsval.New()
-				v.SetRoot(jsval.Any())
-			} else {
-				sh, err := resolveSchema(e.Schema, p.schema)
-				if err != nil {
-					return nil, errors.Wrapf(err, "failed to resolve, %s", id)
-				}
-				b := builder.New()
-				v, err = b.BuildWithCtx(sh, p.schema)
-				if err != nil {
-					return nil, errors.Wrapf(err, "failed to build validator: %s", id)
-				}
+		for i, e := range hsc.Links {
+			ptr := []string{"definitions", id, "links", strconv.Itoa(i)}
+			v, err := p.buildJsVal(e.Schema)
+			if err != nil {
+				errs.add(errors.Wrap(err, "failed to build validator"), append(ptr, "schema")...)
+				continue
 			}
-			v.Name = varfmt.PublicVarName(
-				strings.Replace(id+strings.Title(e.Rel), "-", "_", -1) + "Validator")
+			v.Name = jsValName(id, e.Rel, "Validator")
 			validators = append(validators, v)
+			if !withResponse {
+				continue
+			}
+			target := e.TargetSchema
+			if target == nil {
+				target = p.responseSchema(id, e.Rel)
+			}
+			rv, err := p.buildJsVal(target)
+			if err != nil {
+				errs.add(errors.Wrap(err, "failed to build response validator"), append(ptr, "targetSchema")...)
+				continue
+			}
+			rv.Name = jsValName(id, e.Rel, "ResponseValidator")
+			validators = append(validators, rv)
 		}
 	}
+	if err := p.errorOf(errs); err != nil {
+		return nil, err
+	}
 	return sortValidator(validators), nil
 }
 
+// jsValName returns go variable name of jsval validator, e.g. TaskCreateValidator
+func jsValName(id, rel, suffix string) string {
+	return varfmt.PublicVarName(strings.Replace(id+strings.Title(rel), "-", "_", -1) + suffix)
+}
+
+// buildJsVal builds validator of schema, or validator accepting anything if schema is nil
+func (p *Parser) buildJsVal(sc *schema.Schema) (*jsval.JSVal, error) {
+	if sc == nil {
+		v := jsval.New()
+		v.SetRoot(jsval.Any())
+		return v, nil
+	}
+	sh, err := resolveSchema(sc, p.schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve")
+	}
+	b := builder.New()
+	return b.BuildWithCtx(sh, p.schema)
+}
+
+// responseSchema returns schema of link response without targetSchema, main resource,
+// or list of main resource for instances link
+func (p *Parser) responseSchema(id, rel string) *schema.Schema {
+	ref := schema.New()
+	ref.Reference = "#/definitions/" + id
+	if rel != "instances" {
+		return ref
+	}
+	sc := schema.New()
+	sc.Type = schema.PrimitiveTypes{schema.ArrayType}
+	sc.Items = &schema.ItemSpec{Schemas: schema.SchemaList{ref}}
+	return sc
+}
+
 func isMainResource(ref string) bool {
 	if ref == "" {
 		return false
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
 	schema "github.com/lestrrat-go/jsschema"
@@ -30,7 +31,7 @@ func TestParseResources(t *testing.T) {
 
 func TestParseJsValValidators(t *testing.T) {
 	parser := testNewParser(t)
-	vals, err := parser.ParseJsValValidators()
+	vals, err := parser.ParseJsValValidators(false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -39,6 +40,26 @@ func TestParseJsValValidators(t *testing.T) {
 	}
 }
 
+func TestParseJsValResponseValidators(t *testing.T) {
+	parser := testNewParser(t)
+	vals, err := parser.ParseJsValValidators(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, v := range vals {
+		names[v.Name] = true
+	}
+	for _, n := range []string{
+		"TaskCreateValidator", "TaskCreateResponseValidator",
+		"TaskInstancesResponseValidator", "UserSelfResponseValidator",
+	} {
+		if !names[n] {
+			t.Errorf("%s not found", n)
+		}
+	}
+}
+
 func TestParseValidators(t *testing.T) {
 	parser := testNewParser(t)
 	vals, err := parser.ParseValidators()
@@ -68,3 +89,230 @@ func TestParseActions(t *testing.T) {
 		}
 	}
 }
+
+func TestParsePathParams(t *testing.T) {
+	parser := testNewParser(t)
+	params, err := parser.parsePathParams("/tasks/{(#/definitions/task/definitions/identity)}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 1 {
+		t.Fatalf("want 1 param got %d", len(params))
+	}
+	if params[0].Name != "id" {
+		t.Errorf("want id got %s", params[0].Name)
+	}
+	if params[0].GoType() != "string" {
+		t.Errorf("want string got %s", params[0].GoType())
+	}
+}
+
+func TestMergeAllOf(t *testing.T) {
+	str := &schema.Schema{Type: schema.PrimitiveTypes{schema.StringType}}
+	num := &schema.Schema{Type: schema.PrimitiveTypes{schema.IntegerType}}
+	sc := &schema.Schema{
+		AllOf: schema.SchemaList{
+			{Reference: "#/definitions/user"},
+			{
+				Type:       schema.PrimitiveTypes{schema.ObjectType},
+				Properties: map[string]*schema.Schema{"name": str},
+				Required:   []string{"name"},
+			},
+			{
+				Properties: map[string]*schema.Schema{"name": str, "age": num},
+				Required:   []string{"age"},
+			},
+		},
+	}
+	merged, embeds, err := mergeAllOf(sc, sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embeds) != 1 || embeds[0] != "#/definitions/user" {
+		t.Errorf("want [#/definitions/user] got %v", embeds)
+	}
+	if len(merged.Properties) != 2 {
+		t.Errorf("want 2 properties got %d", len(merged.Properties))
+	}
+	if !merged.IsPropRequired("name") || !merged.IsPropRequired("age") {
+		t.Errorf("want name and age required got %v", merged.Required)
+	}
+
+	sc.AllOf = append(sc.AllOf, &schema.Schema{
+		Properties: map[string]*schema.Schema{"age": str},
+	})
+	if _, _, err := mergeAllOf(sc, sc); err == nil {
+		t.Error("want conflict error")
+	}
+}
+
+func TestMergeAllOfNestedConflict(t *testing.T) {
+	root := readTestSchema(t, `{
+  "definitions": {
+    "meta": {
+      "type": "object",
+      "properties": {"a": {"type": "string"}}
+    },
+    "same": {
+      "allOf": [
+        {"properties": {"meta": {"$ref": "#/definitions/meta"}}},
+        {"properties": {"meta": {"type": "object", "properties": {"a": {"type": "string"}}}}}
+      ]
+    },
+    "properties": {
+      "allOf": [
+        {"properties": {"meta": {"$ref": "#/definitions/meta"}}},
+        {"properties": {"meta": {"type": "object", "properties": {"b": {"type": "string"}}}}}
+      ]
+    },
+    "nested": {
+      "allOf": [
+        {"properties": {"meta": {"$ref": "#/definitions/meta"}}},
+        {"properties": {"meta": {"type": "object", "properties": {"a": {"type": "integer"}}}}}
+      ]
+    },
+    "items": {
+      "allOf": [
+        {"properties": {"tags": {"type": "array", "items": {"type": "string"}}}},
+        {"properties": {"tags": {"type": "array", "items": {"$ref": "#/definitions/meta"}}}}
+      ]
+    }
+  }
+}`)
+	cases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "same"},
+		{name: "properties", expected: "conflicting properties in allOf for meta: [a] and [b]"},
+		{name: "nested", expected: "conflicting types in allOf for meta.a: string() and integer()"},
+		{name: "items", expected: "conflicting types in allOf for tags[]: string() and object()"},
+	}
+	for _, c := range cases {
+		_, _, err := mergeAllOf(root.Definitions[c.name], root)
+		switch {
+		case c.expected == "" && err != nil:
+			t.Errorf("%s: want no error got %v", c.name, err)
+		case c.expected != "" && (err == nil || err.Error() != c.expected):
+			t.Errorf("%s: want %s got %v", c.name, c.expected, err)
+		}
+	}
+}
+
+// readTestSchema reads schema from JSON src, so that references can be resolved
+func readTestSchema(t *testing.T, src string) *schema.Schema {
+	sc, err := schema.Read(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sc
+}
+
+func TestResolveSchemaCircular(t *testing.T) {
+	root := readTestSchema(t, `{
+  "definitions": {
+    "a": {"$ref": "#/definitions/b"},
+    "b": {"$ref": "#/definitions/a"},
+    "c": {"$ref": "#/definitions/d"},
+    "d": {"type": "string"},
+    "x": {
+      "definitions": {
+        "a": {"allOf": [{"$ref": "#/definitions/x/definitions/b"}]},
+        "b": {"allOf": [{"$ref": "#/definitions/x/definitions/a"}]}
+      }
+    }
+  }
+}`)
+	_, err := resolveSchema(root.Definitions["a"], root)
+	expected := "circular reference: #/definitions/b -> #/definitions/a -> #/definitions/b"
+	if err == nil || err.Error() != expected {
+		t.Errorf("want %s got %v", expected, err)
+	}
+
+	sc, err := resolveSchema(root.Definitions["c"], root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.Type) != 1 || sc.Type[0] != schema.StringType {
+		t.Errorf("want string got %v", sc.Type)
+	}
+
+	// allOf members referring main resources are embedded, not merged
+	x := root.Definitions["x"]
+	if _, _, err := mergeAllOf(x.Definitions["a"], root); err == nil {
+		t.Error("want circular allOf error")
+	}
+}
+
+func TestNewPropertyRecursive(t *testing.T) {
+	root := readTestSchema(t, `{
+  "definitions": {
+    "tree": {
+      "type": "object",
+      "definitions": {
+        "node": {
+          "type": "object",
+          "properties": {
+            "name": {"type": "string"},
+            "parent": {"$ref": "#/definitions/tree/definitions/node"},
+            "children": {
+              "type": "array",
+              "items": {"$ref": "#/definitions/tree/definitions/node"}
+            }
+          },
+          "required": ["name"]
+        }
+      },
+      "properties": {
+        "root": {"$ref": "#/definitions/tree/definitions/node"}
+      },
+      "required": ["root"]
+    }
+  }
+}`)
+	tree := root.Definitions["tree"]
+	node := tree.Definitions["node"]
+
+	p, err := NewProperty("root", tree.Properties["root"], tree, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.InlineProperties = sortProperties(p.InlineProperties)
+	op := FormatOption{}
+	if p.RecursiveType != "tree_node" || p.GoType(op) != "TreeNode" {
+		t.Errorf("want TreeNode got %s %s", p.RecursiveType, p.GoType(op))
+	}
+	types := map[string]string{
+		"children": "[]TreeNode",
+		"name":     "string",
+		"parent":   "*TreeNode",
+	}
+	if len(p.InlineProperties) != len(types) {
+		t.Fatalf("want %d properties got %d", len(types), len(p.InlineProperties))
+	}
+	for _, ip := range p.InlineProperties {
+		if ip.GoType(op) != types[ip.Name] {
+			t.Errorf("%s: want %s got %s", ip.Name, types[ip.Name], ip.GoType(op))
+		}
+	}
+
+	enums := make(map[string]*Enum)
+	unions := make(map[string]*Union)
+	structs := make(map[string]*Property)
+	if err := collectTypes([]*Property{p}, enums, unions, structs); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := structs["tree_node"]; !ok || len(structs) != 1 {
+		t.Errorf("want tree_node struct got %v", structs)
+	}
+
+	// array of recursive object
+	children := node.Properties["children"]
+	p, err = NewProperty("nodes", children, tree, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GoType(op) != "[]TreeNode" || len(p.InlineProperties) != 3 {
+		t.Errorf("want []TreeNode got %s", p.GoType(op))
+	}
+}
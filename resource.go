@@ -2,21 +2,28 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"regexp"
 	"strings"
+	"unicode"
 
 	"github.com/achiku/varfmt"
 	schema "github.com/lestrrat-go/jsschema"
+	"github.com/pkg/errors"
 )
 
 // Resource plain resource
 type Resource struct {
-	Name       string
-	Title      string
-	Schema     *schema.Schema
-	Properties []*Property
-	IsPrimary  bool
+	Name                 string
+	Title                string
+	Description          string
+	Schema               *schema.Schema
+	Properties           []*Property
+	Embeds               []string
+	AdditionalProperties *Property
+	IsPrimary            bool
 }
 
 // FormatOption output struct format option
@@ -24,7 +31,8 @@ type FormatOption struct {
 	Validator bool
 	Schema    bool
 	UseTitle  bool
-	UseNull   bool
+	Optional  Optional
+	TypeMap   TypeMap
 }
 
 // Struct returns struct go representation of resource
@@ -32,27 +40,108 @@ func (rs *Resource) Struct(op FormatOption) []byte {
 	name := varfmt.PublicVarName(normalize(rs.Name))
 	var src bytes.Buffer
 	fmt.Fprintf(&src, "// %s struct for %s resource\n", name, rs.Name)
+	fmt.Fprint(&src, docComment(rs.Description))
 	fmt.Fprintf(&src, "type %s struct {\n", name)
+	fmt.Fprint(&src, embedFields(rs.Embeds))
 	for _, p := range rs.Properties {
 		fmt.Fprintf(&src, "%s\n", p.Field(op))
 	}
+	fmt.Fprint(&src, additionalPropertiesField(rs.AdditionalProperties, op))
 	fmt.Fprint(&src, "}\n\n")
+	src.Write(additionalPropertiesFuncs(name, rs.Properties, rs.AdditionalProperties, op))
+	src.Write(defaultFuncs(name, rs.Properties, op))
 	return src.Bytes()
 }
 
 // Property resource properties
 type Property struct {
-	Name             string
-	Format           string
-	Types            schema.PrimitiveTypes
-	SecondTypes      schema.PrimitiveTypes
-	PropType         PropType
-	Required         bool
-	Reference        string
-	SecondReference  string
-	InlineProperties []*Property
-	Pattern          *regexp.Regexp
-	Schema           *schema.Schema
+	Name                 string
+	Format               string
+	SecondFormat         string
+	Types                schema.PrimitiveTypes
+	SecondTypes          schema.PrimitiveTypes
+	PropType             PropType
+	Required             bool
+	Reference            string
+	SecondReference      string
+	InlineProperties     []*Property
+	Pattern              *regexp.Regexp
+	Schema               *schema.Schema
+	ItemSchema           *schema.Schema
+	Enum                 *Enum
+	Union                *Union
+	Embeds               []string
+	AdditionalProperties *Property
+	GoTypeOverride       *GoType
+	Description          string
+	Example              interface{}
+	ReadOnly             bool
+	WriteOnly            bool
+	Default              interface{}
+	ValidatorName        string
+	RecursiveType        string
+}
+
+// nameTypes names enum and union types defined without reference by property path
+func nameTypes(props []*Property, prefix string) {
+	for _, p := range props {
+		path := prefix + "_" + normalize(p.Name)
+		if p.Enum != nil && p.Enum.Name == "" {
+			p.Enum.Name = path
+		}
+		if p.Union != nil {
+			if p.Union.Name == "" {
+				p.Union.Name = path
+			}
+			for _, v := range p.Union.Variants {
+				nameTypes([]*Property{v.Property}, p.Union.Name)
+			}
+		}
+		if p.AdditionalProperties != nil {
+			// inline object with additional properties is named like recursive object,
+			// since its catch-all map is (un)marshalled by methods
+			if len(p.InlineProperties) != 0 && p.RecursiveType == "" {
+				p.RecursiveType = path
+			}
+			nameTypes([]*Property{p.AdditionalProperties}, path)
+		}
+		nameTypes(p.InlineProperties, path)
+	}
+}
+
+// collectTypes collects enum, union and recursive object types in properties
+func collectTypes(props []*Property, enums map[string]*Enum, unions map[string]*Union, structs map[string]*Property) error {
+	for _, p := range props {
+		if p.RecursiveType != "" && len(p.InlineProperties) != 0 {
+			structs[p.RecursiveType] = p
+		}
+		if e := p.Enum; e != nil {
+			if ex, ok := enums[e.TypeName()]; ok && !reflect.DeepEqual(ex.Values, e.Values) {
+				return errors.Errorf("enum %s is defined with different values", e.TypeName())
+			}
+			enums[e.TypeName()] = e
+		}
+		if u := p.Union; u != nil {
+			if ex, ok := unions[u.TypeName()]; ok && ex != u && ex.signature() != u.signature() {
+				return errors.Errorf("union %s is defined with different variants or discriminator", u.TypeName())
+			}
+			unions[u.TypeName()] = u
+			for _, v := range u.Variants {
+				if err := collectTypes([]*Property{v.Property}, enums, unions, structs); err != nil {
+					return err
+				}
+			}
+		}
+		if p.AdditionalProperties != nil {
+			if err := collectTypes([]*Property{p.AdditionalProperties}, enums, unions, structs); err != nil {
+				return err
+			}
+		}
+		if err := collectTypes(p.InlineProperties, enums, unions, structs); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func normalize(n string) string {
@@ -99,9 +188,19 @@ func (pr *Property) refToStructName() string {
 	return normalize(strings.Replace(ref, "#/definitions/", "", 1))
 }
 
+// embedFields returns anonymous fields of main resources
+func embedFields(refs []string) string {
+	var src bytes.Buffer
+	for _, ref := range refs {
+		fmt.Fprintf(&src, "%s\n", varfmt.PublicVarName(refToStructName(ref)))
+	}
+	return src.String()
+}
+
 func (pr *Property) inlineOjbect(op FormatOption) string {
 	var inline bytes.Buffer
 	fmt.Fprint(&inline, "struct{\n")
+	fmt.Fprint(&inline, embedFields(pr.Embeds))
 	for _, p := range pr.InlineProperties {
 		fmt.Fprintf(&inline, "%s\n", p.Field(op))
 	}
@@ -109,9 +208,31 @@ func (pr *Property) inlineOjbect(op FormatOption) string {
 	return inline.String()
 }
 
+// RecursiveStruct returns go type definition of recursive object, or inline object
+// with additional properties
+func (pr *Property) RecursiveStruct(op FormatOption) []byte {
+	name := varfmt.PublicVarName(pr.RecursiveType)
+	var src bytes.Buffer
+	if pr.AdditionalProperties != nil {
+		fmt.Fprintf(&src, "// %s struct for %s with additional properties\n", name, pr.RecursiveType)
+	} else {
+		fmt.Fprintf(&src, "// %s struct for recursive %s\n", name, pr.RecursiveType)
+	}
+	fmt.Fprintf(&src, "type %s struct{\n", name)
+	fmt.Fprint(&src, embedFields(pr.Embeds))
+	for _, p := range pr.InlineProperties {
+		fmt.Fprintf(&src, "%s\n", p.Field(op))
+	}
+	fmt.Fprint(&src, additionalPropertiesField(pr.AdditionalProperties, op))
+	fmt.Fprint(&src, "}\n\n")
+	src.Write(additionalPropertiesFuncs(name, pr.InlineProperties, pr.AdditionalProperties, op))
+	return src.Bytes()
+}
+
 func (pr *Property) inlineListOjbect(op FormatOption) string {
 	var inline bytes.Buffer
 	fmt.Fprint(&inline, "[]struct{\n")
+	fmt.Fprint(&inline, embedFields(pr.Embeds))
 	for _, p := range pr.InlineProperties {
 		fmt.Fprintf(&inline, "%s\n", p.Field(op))
 	}
@@ -119,20 +240,31 @@ func (pr *Property) inlineListOjbect(op FormatOption) string {
 	return inline.String()
 }
 
-// Field returns go struct field representation of property
-func (pr *Property) Field(op FormatOption) []byte {
-	fieldName := varfmt.PublicVarName(normalize(pr.Name))
-	var (
-		t     string
-		empty string
-	)
+// GoType returns go type of property
+func (pr *Property) GoType(op FormatOption) string {
+	if pr.GoTypeOverride != nil {
+		return pr.GoTypeOverride.Expr
+	}
+	optional := pr.Types.Contains(schema.NullType) || !pr.Required
+	var t string
 	switch {
 	case pr.PropType == PropTypeScalar:
 		t = pr.ScalarType(op)
+	case pr.PropType == PropTypeUnion:
+		t = pr.Union.TypeName()
+		if optional {
+			t = op.Optional.ref(t)
+		}
 	case pr.PropType == PropTypeArray:
-		if len(pr.InlineProperties) == 0 && pr.IsRefToMainResource() && pr.SecondTypes.Contains(schema.ObjectType) {
+		if pr.Union != nil {
+			// an array of union type
+			t = fmt.Sprintf("[]%s", pr.Union.TypeName())
+		} else if len(pr.InlineProperties) == 0 && pr.IsRefToMainResource() && pr.SecondTypes.Contains(schema.ObjectType) {
 			// referecnce to main resource object
 			t = fmt.Sprintf("[]%s", varfmt.PublicVarName(normalize(pr.refToStructName())))
+		} else if pr.RecursiveType != "" {
+			// list of recursive object
+			t = fmt.Sprintf("[]%s", varfmt.PublicVarName(pr.RecursiveType))
 		} else if len(pr.InlineProperties) != 0 {
 			// inline list object
 			t = pr.inlineListOjbect(op)
@@ -140,127 +272,204 @@ func (pr *Property) Field(op FormatOption) []byte {
 			// an array of primitive types
 			t = fmt.Sprintf("[]%s", pr.ScalarType(op))
 		}
+		if optional {
+			// nil slice already represents null except generic mode
+			t = op.Optional.nilable(t)
+		}
 	case pr.Types.Contains(schema.ObjectType) && pr.IsRefToMainResource():
 		// reference to main resource object
-		t = fmt.Sprintf("*%s", varfmt.PublicVarName(normalize(pr.refToStructName())))
+		t = varfmt.PublicVarName(normalize(pr.refToStructName()))
+		if optional || op.Optional == OptionalNone {
+			t = op.Optional.ref(t)
+		}
+	case pr.Types.Contains(schema.ObjectType) && pr.RecursiveType != "" && len(pr.InlineProperties) == 0:
+		// reference to recursive object, which has to be a pointer
+		t = "*" + varfmt.PublicVarName(pr.RecursiveType)
+	case pr.Types.Contains(schema.ObjectType) && pr.RecursiveType != "":
+		// recursive object
+		t = varfmt.PublicVarName(pr.RecursiveType)
+		if optional && op.Optional != OptionalNone {
+			t = op.Optional.ref(t)
+		}
+	case pr.Types.Contains(schema.ObjectType) && !pr.IsRefToMainResource() &&
+		pr.AdditionalProperties != nil && len(pr.InlineProperties) == 0 && len(pr.Embeds) == 0:
+		// object with additional properties only
+		t = mapType(pr.AdditionalProperties, op)
+		if optional {
+			t = op.Optional.nilable(t)
+		}
 	case pr.Types.Contains(schema.ObjectType) && !pr.IsRefToMainResource():
 		// inline object
 		t = pr.inlineOjbect(op)
+		if optional && op.Optional != OptionalNone {
+			t = op.Optional.ref(t)
+		}
 	}
+	return t
+}
+
+// Field returns go struct field representation of property
+func (pr *Property) Field(op FormatOption) []byte {
+	fieldName := varfmt.PublicVarName(normalize(pr.Name))
+	t := pr.GoType(op)
+	var empty string
 	if !pr.Required {
 		empty = ",omitempty"
 	}
 
 	var src bytes.Buffer
+	fmt.Fprint(&src, pr.Comment())
 	fmt.Fprintf(&src, "%s %s `json:\"%s%s\"", fieldName, t, pr.Name, empty)
 	if op.Schema {
 		fmt.Fprintf(&src, " schema:\"%s\"", pr.Name)
 	}
 
 	if op.Validator {
-		if pr.Required && pr.Pattern == nil {
-			fmt.Fprint(&src, " validate:\"required\"")
-		} else if pr.Required && pr.Pattern != nil {
-			fmt.Fprintf(&src, " validate:\"required,%s\"", fieldName+"Validator")
+		var pattern string
+		if pr.ValidatorName != "" {
+			pattern = varfmt.PublicVarName(pr.ValidatorName) + "Validator"
+		}
+		if tag := pr.ValidateTag(pattern, op); tag != "" {
+			fmt.Fprintf(&src, " validate:\"%s\"", tag)
 		}
 	}
 	fmt.Fprint(&src, "`")
 	return src.Bytes()
 }
 
+// Comment returns go doc comment of property from description, example and readOnly
+func (pr *Property) Comment() string {
+	var src bytes.Buffer
+	src.WriteString(docComment(pr.Description))
+	if pr.Example != nil {
+		b, err := json.Marshal(pr.Example)
+		if err == nil {
+			fmt.Fprintf(&src, "// Example: %s\n", b)
+		}
+	}
+	if pr.ReadOnly {
+		src.WriteString("// Read only\n")
+	}
+	return src.String()
+}
+
+// docComment returns text as go comment lines
+func docComment(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+	var src bytes.Buffer
+	for _, l := range strings.Split(text, "\n") {
+		l = strings.TrimRightFunc(l, unicode.IsSpace)
+		if l == "" {
+			src.WriteString("//\n")
+			continue
+		}
+		fmt.Fprintf(&src, "// %s\n", l)
+	}
+	return src.String()
+}
+
 // ScalarType returns go scalar type
 func (pr *Property) ScalarType(op FormatOption) string {
-	var types schema.PrimitiveTypes
+	var (
+		types  schema.PrimitiveTypes
+		format string
+	)
 	if pr.Types.Contains(schema.ArrayType) {
 		types = pr.SecondTypes
+		format = pr.SecondFormat
 	} else {
 		types = pr.Types
+		format = pr.Format
 	}
-	if op.UseNull {
-		if types.Contains(schema.NullType) || !pr.Required {
-			switch {
-			case types.Contains(schema.NumberType):
-				return "null.Float"
-			case types.Contains(schema.IntegerType):
-				return "null.Int"
-			case types.Contains(schema.BooleanType):
-				return "bool"
-			case types.Contains(schema.StringType):
-				if pr.Format == "date-time" {
-					return "null.Time"
-				}
-				return "null.String"
-			default:
-				return ""
-			}
-		} else {
-			switch {
-			case types.Contains(schema.NumberType):
-				return "float64"
-			case types.Contains(schema.IntegerType):
-				return "int64"
-			case types.Contains(schema.BooleanType):
-				return "bool"
-			case types.Contains(schema.StringType):
-				if pr.Format == "date-time" {
-					return "time.Time"
-				}
-				return "string"
-			default:
-				return ""
-			}
+	// array items are optional only if nullable
+	optional := types.Contains(schema.NullType) ||
+		(!pr.Required && !pr.Types.Contains(schema.ArrayType))
+	if pr.Enum != nil {
+		if optional || pr.Enum.Nullable {
+			return op.Optional.wrap(pr.Enum.TypeName())
+		}
+		return pr.Enum.TypeName()
+	}
+	if m, ok := op.TypeMap.lookup(format); ok {
+		if optional {
+			return m.nullType(op.Optional)
 		}
+		return m.Type.Expr
 	}
+	var t string
 	switch {
 	case types.Contains(schema.NumberType):
-		return "float64"
+		t = "float64"
 	case types.Contains(schema.IntegerType):
-		return "int64"
+		t = "int64"
 	case types.Contains(schema.BooleanType):
-		return "bool"
+		t = "bool"
 	case types.Contains(schema.StringType):
-		if pr.Format == "date-time" {
-			return "time.Time"
-		}
-		return "string"
+		t = "string"
 	default:
 		return ""
 	}
+	if optional {
+		return op.Optional.wrap(t)
+	}
+	return t
 }
 
 // Action endpoint
 type Action struct {
-	Encoding string
-	Href     string
-	Method   string
-	Rel      string
-	Title    string
-	Request  *Resource
-	Response *Resource
+	Encoding   string
+	Href       string
+	Method     string
+	Rel        string
+	Title      string
+	PathParams []PathParam
+	Request    *Resource
+	Response   *Resource
 }
 
-// RequestStruct request struct
-func (a *Action) RequestStruct(op FormatOption) []byte {
-	if a.Request == nil {
-		return []byte("")
-	}
+// Name returns go name of action, e.g. TaskCreate
+func (a *Action) Name(op FormatOption) string {
 	var n string
 	if op.UseTitle {
 		n = a.Title
 	} else {
 		n = a.Rel
 	}
-	name := varfmt.PublicVarName(
-		normalize(a.Response.Name+strings.Title(n)) + "Request")
+	return varfmt.PublicVarName(normalize(a.Response.Name + strings.Title(n)))
+}
+
+// RequestStructName request struct name
+func (a *Action) RequestStructName(op FormatOption) string {
+	return a.Name(op) + "Request"
+}
+
+// ResponseStructName response struct name
+func (a *Action) ResponseStructName(op FormatOption) string {
+	return a.Name(op) + "Response"
+}
+
+// RequestStruct request struct
+func (a *Action) RequestStruct(op FormatOption) []byte {
+	if a.Request == nil {
+		return []byte("")
+	}
+	name := a.RequestStructName(op)
 
 	var src bytes.Buffer
 	fmt.Fprintf(&src, "// %s struct for %s\n", name, a.Request.Name)
 	fmt.Fprintf(&src, "// %s: %s\n", a.Method, a.Href)
 	fmt.Fprintf(&src, "type %s struct {\n", name)
+	fmt.Fprint(&src, embedFields(a.Request.Embeds))
 	for _, p := range a.Request.Properties {
 		fmt.Fprintf(&src, "%s\n", p.Field(op))
 	}
+	fmt.Fprint(&src, additionalPropertiesField(a.Request.AdditionalProperties, op))
 	fmt.Fprint(&src, "}\n\n")
+	src.Write(additionalPropertiesFuncs(name, a.Request.Properties, a.Request.AdditionalProperties, op))
+	src.Write(defaultFuncs(name, a.Request.Properties, op))
 	return src.Bytes()
 }
 
@@ -269,14 +478,7 @@ func (a *Action) ResponseStruct(op FormatOption) []byte {
 	if a.Response == nil {
 		return []byte("")
 	}
-	var n string
-	if op.UseTitle {
-		n = a.Title
-	} else {
-		n = a.Rel
-	}
-	name := varfmt.PublicVarName(
-		normalize(a.Response.Name+strings.Title(n)) + "Response")
+	name := a.ResponseStructName(op)
 	orgName := varfmt.PublicVarName(normalize(a.Response.Name))
 
 	var src bytes.Buffer
@@ -297,17 +499,23 @@ func (a *Action) ResponseStruct(op FormatOption) []byte {
 	case a.Response.Schema != nil && a.Response.Schema.Reference == "" && len(a.Response.Properties) != 0:
 		// log.Printf("with target schema + inline resource: %s: %s", name, a.Response.Schema.Reference)
 		fmt.Fprintf(&src, "type %s struct {\n", name)
+		fmt.Fprint(&src, embedFields(a.Response.Embeds))
 		for _, p := range a.Response.Properties {
 			fmt.Fprintf(&src, "%s\n", p.Field(op))
 		}
+		fmt.Fprint(&src, additionalPropertiesField(a.Response.AdditionalProperties, op))
 		fmt.Fprint(&src, "}\n\n")
+		src.Write(additionalPropertiesFuncs(name, a.Response.Properties, a.Response.AdditionalProperties, op))
 	case a.Response.Schema != nil && !IsRefToMainResource(a.Response.Schema.Reference):
 		// log.Printf("with target schema + deep inline resource: %s: %s", name, a.Response.Schema.Reference)
 		fmt.Fprintf(&src, "type %s struct {\n", name)
+		fmt.Fprint(&src, embedFields(a.Response.Embeds))
 		for _, p := range a.Response.Properties {
 			fmt.Fprintf(&src, "%s\n", p.Field(op))
 		}
+		fmt.Fprint(&src, additionalPropertiesField(a.Response.AdditionalProperties, op))
 		fmt.Fprint(&src, "}\n\n")
+		src.Write(additionalPropertiesFuncs(name, a.Response.Properties, a.Response.AdditionalProperties, op))
 	}
 	return src.Bytes()
 }
@@ -135,3 +135,69 @@ func TestResourceStruct(t *testing.T) {
 	}
 	t.Logf("%s", ss)
 }
+
+func TestResourceStructEmbeds(t *testing.T) {
+	res := Resource{
+		Name:   "admin_user",
+		Embeds: []string{"#/definitions/user"},
+		Properties: []*Property{
+			{
+				Name:     "role",
+				Types:    []schema.PrimitiveType{schema.StringType},
+				Required: true,
+			},
+		},
+	}
+	ss, err := format.Source(res.Struct(FormatOption{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "// AdminUser struct for admin_user resource\ntype AdminUser struct {\n\tUser\n\tRole string `json:\"role\"`\n}\n\n"
+	if string(ss) != expected {
+		t.Errorf("want %s got %s", expected, ss)
+	}
+}
+
+func TestResourceStructComments(t *testing.T) {
+	res := Resource{
+		Name:        "task",
+		Description: "A task to be done.\n\nTasks belong to a user.",
+		Properties: []*Property{
+			&Property{
+				Name:        "id",
+				Types:       []schema.PrimitiveType{schema.StringType},
+				Required:    true,
+				Description: "unique identifier of task",
+				Example:     "01234567-89ab-cdef-0123-456789abcdef",
+				ReadOnly:    true,
+			},
+			&Property{
+				Name:     "spent",
+				Types:    []schema.PrimitiveType{schema.IntegerType},
+				Required: true,
+				Example:  float64(10),
+			},
+		},
+	}
+	expected := `// Task struct for task resource
+// A task to be done.
+//
+// Tasks belong to a user.
+type Task struct {
+	// unique identifier of task
+	// Example: "01234567-89ab-cdef-0123-456789abcdef"
+	// Read only
+	ID string ` + "`json:\"id\"`" + `
+	// Example: 10
+	Spent int64 ` + "`json:\"spent\"`" + `
+}
+
+`
+	ss, err := format.Source(res.Struct(FormatOption{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ss) != expected {
+		t.Errorf("want %s got %s", expected, ss)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaError error at JSON pointer in schema. line and column are set if
+// schema is read from file
+type SchemaError struct {
+	Pointer string
+	File    string
+	Line    int
+	Column  int
+	Err     error
+}
+
+func (e *SchemaError) Error() string {
+	msg := "#" + e.Pointer + ": " + e.Err.Error()
+	if e.Line == 0 {
+		return msg
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, msg)
+}
+
+// ErrorList errors collected while parsing schema
+type ErrorList []*SchemaError
+
+func (l ErrorList) Error() string {
+	var msgs []string
+	for _, e := range l {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// add appends err at pointer of segments, e.g. "properties", "tags". pointers of
+// errors already collected in err are prefixed with segments
+func (l *ErrorList) add(err error, segments ...string) {
+	var prefix string
+	for _, s := range segments {
+		prefix += "/" + pointerEscaper.Replace(s)
+	}
+	switch e := err.(type) {
+	case ErrorList:
+		for _, se := range e {
+			*l = append(*l, &SchemaError{Pointer: prefix + se.Pointer, Err: se.Err})
+		}
+	case *SchemaError:
+		*l = append(*l, &SchemaError{Pointer: prefix + e.Pointer, Err: e.Err})
+	default:
+		*l = append(*l, &SchemaError{Pointer: prefix, Err: err})
+	}
+}
+
+// err returns errors sorted by pointer, or nil if empty
+func (l ErrorList) err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].Pointer < l[j].Pointer
+	})
+	return l
+}
+
+// locate sets file, line and column of errors in src. errors at pointers
+// which can't be found in src, e.g. ones following $ref, are left as is
+func (l ErrorList) locate(file string, src []byte) {
+	for _, e := range l {
+		offset := locatePointer(src, e.Pointer)
+		if offset < 0 {
+			continue
+		}
+		e.File = file
+		e.Line, e.Column = 1, 1
+		for _, c := range src[:offset] {
+			if c == '\n' {
+				e.Line++
+				e.Column = 1
+			} else {
+				e.Column++
+			}
+		}
+	}
+}
+
+// locatePointer returns offset of value at JSON pointer in JSON src, or -1 if not found
+func locatePointer(src []byte, ptr string) int {
+	l := &locator{src: src, target: ptr, offset: -1}
+	l.value("")
+	return l.offset
+}
+
+// locator scans JSON without decoding to find offset of value at target pointer
+type locator struct {
+	src    []byte
+	pos    int
+	target string
+	offset int
+}
+
+func (l *locator) skipSpace() {
+	for l.pos < len(l.src) && strings.IndexByte(" \t\r\n", l.src[l.pos]) >= 0 {
+		l.pos++
+	}
+}
+
+func (l *locator) value(ptr string) {
+	l.skipSpace()
+	if l.pos >= len(l.src) {
+		return
+	}
+	if ptr == l.target {
+		l.offset = l.pos
+		return
+	}
+	switch l.src[l.pos] {
+	case '{':
+		l.pos++
+		for l.offset < 0 {
+			l.skipSpace()
+			if l.pos >= len(l.src) {
+				return
+			}
+			switch l.src[l.pos] {
+			case '}':
+				l.pos++
+				return
+			case ',':
+				l.pos++
+				continue
+			}
+			key := l.str()
+			l.skipSpace()
+			if l.pos < len(l.src) && l.src[l.pos] == ':' {
+				l.pos++
+			}
+			l.value(ptr + "/" + pointerEscaper.Replace(key))
+		}
+	case '[':
+		l.pos++
+		for i := 0; l.offset < 0; {
+			l.skipSpace()
+			if l.pos >= len(l.src) {
+				return
+			}
+			switch l.src[l.pos] {
+			case ']':
+				l.pos++
+				return
+			case ',':
+				l.pos++
+				continue
+			}
+			l.value(ptr + "/" + strconv.Itoa(i))
+			i++
+		}
+	case '"':
+		l.str()
+	default:
+		for l.pos < len(l.src) && strings.IndexByte(",}] \t\r\n", l.src[l.pos]) < 0 {
+			l.pos++
+		}
+	}
+}
+
+// str scans string at current position and returns it unquoted
+func (l *locator) str() string {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.src) && l.src[l.pos] != '"' {
+		if l.src[l.pos] == '\\' {
+			l.pos++
+		}
+		l.pos++
+	}
+	l.pos++
+	if l.pos > len(l.src) {
+		l.pos = len(l.src)
+	}
+	// malformed string is scanned as empty
+	var s string
+	json.Unmarshal(l.src[start:l.pos], &s)
+	return s
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func TestErrorListAdd(t *testing.T) {
+	var inner ErrorList
+	inner.add(errors.New("array type has to have an item"), "properties", "tags")
+	inner.add(errors.New("failed to resolve"), "items", "properties", "a/b")
+
+	var errs ErrorList
+	errs.add(inner, "definitions", "task", "links", "2", "schema")
+	errs.add(errors.New("resource not found: task"), "definitions", "task", "links", "0")
+	expected := "#/definitions/task/links/0: resource not found: task\n" +
+		"#/definitions/task/links/2/schema/items/properties/a~1b: failed to resolve\n" +
+		"#/definitions/task/links/2/schema/properties/tags: array type has to have an item"
+	if err := errs.err(); err == nil || err.Error() != expected {
+		t.Errorf("want %s got %v", expected, err)
+	}
+
+	var empty ErrorList
+	if err := empty.err(); err != nil {
+		t.Errorf("want nil got %v", err)
+	}
+}
+
+func TestErrorListLocate(t *testing.T) {
+	src := []byte(`{
+  "definitions": {
+    "task": {
+      "links": [
+        {"rel": "self"},
+        {
+          "schema": {
+            "properties": {"a/b": {"type": "string"}, "tags": {"type": ["array"]}}
+          }
+        }
+      ]
+    }
+  }
+}`)
+	errs := ErrorList{
+		{Pointer: "/definitions/task/links/1/schema/properties/tags", Err: errors.New("array type has to have an item")},
+		{Pointer: "/definitions/task/links/1/schema/properties/a~1b", Err: errors.New("failed to resolve")},
+		{Pointer: "/definitions/task/links/0/rel", Err: errors.New("invalid")},
+		{Pointer: "/definitions/task/links/1/schema/items/properties/id", Err: errors.New("failed to resolve")},
+	}
+	errs.locate("schema.json", src)
+	expected := []string{
+		"schema.json:8:63: #/definitions/task/links/1/schema/properties/tags: array type has to have an item",
+		"schema.json:8:35: #/definitions/task/links/1/schema/properties/a~1b: failed to resolve",
+		"schema.json:5:17: #/definitions/task/links/0/rel: invalid",
+		"#/definitions/task/links/1/schema/items/properties/id: failed to resolve",
+	}
+	for i, e := range errs {
+		if e.Error() != expected[i] {
+			t.Errorf("want %s got %s", expected[i], e.Error())
+		}
+	}
+}
+
+func TestNewPropertyCollectErrors(t *testing.T) {
+	array := schema.New()
+	array.Type = schema.PrimitiveTypes{schema.ArrayType}
+	unresolvable := schema.New()
+	unresolvable.Reference = "#/definitions/unknown"
+
+	obj := schema.New()
+	obj.Type = schema.PrimitiveTypes{schema.ObjectType}
+	obj.Properties = map[string]*schema.Schema{
+		"tags":  array,
+		"owner": unresolvable,
+	}
+	root := schema.New()
+	_, err := NewProperty("detail", obj, root, root)
+	errs, ok := err.(ErrorList)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("want 2 errors got %v", err)
+	}
+	for i, ptr := range []string{"/properties/owner", "/properties/tags"} {
+		if errs[i].Pointer != ptr {
+			t.Errorf("want %s got %s", ptr, errs[i].Pointer)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/achiku/varfmt"
+)
+
+// serverBase is the router type and its helpers shared by all routes
+const serverBase = `
+// StatusCoder is implemented by errors carrying HTTP status code
+type StatusCoder interface {
+	StatusCode() int
+}
+
+type route struct {
+	method  string
+	pattern *regexp.Regexp
+	handler http.HandlerFunc
+}
+
+// Router routes requests to resource handlers
+type Router struct {
+	routes []route
+}
+
+// ServeHTTP implements http.Handler
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var allowed []string
+	for _, route := range rt.routes {
+		if !route.pattern.MatchString(r.URL.EscapedPath()) {
+			continue
+		}
+		if route.method != r.Method {
+			allowed = append(allowed, route.method)
+			continue
+		}
+		route.handler(w, r)
+		return
+	}
+	if len(allowed) != 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, http.StatusMethodNotAllowed, errors.New(http.StatusText(http.StatusMethodNotAllowed)))
+		return
+	}
+	writeError(w, http.StatusNotFound, errors.New(http.StatusText(http.StatusNotFound)))
+}
+
+var formDecoder = schema.NewDecoder()
+
+func init() {
+	formDecoder.IgnoreUnknownKeys(true)
+	formDecoder.RegisterConverter(time.Time{}, func(s string) reflect.Value {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return reflect.Value{}
+		}
+		return reflect.ValueOf(t)
+	})
+}
+
+func decodeJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+func decodeQuery(r *http.Request, v interface{}) error {
+	return formDecoder.Decode(v, r.URL.Query())
+}
+
+func decodeForm(r *http.Request, v interface{}) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	return formDecoder.Decode(v, r.PostForm)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	if sc, ok := err.(StatusCoder); ok {
+		status = sc.StatusCode()
+	}
+	writeJSON(w, status, map[string]string{"message": err.Error()})
+}
+`
+
+// serverImports imports used by generated server file
+var serverImports = []string{
+	"context",
+	"encoding/json",
+	"errors",
+	"io",
+	"net/http",
+	"reflect",
+	"regexp",
+	"strings",
+	"time",
+	"",
+	"github.com/gorilla/schema",
+}
+
+func handlerInterfaceName(id string) string {
+	return varfmt.PublicVarName(normalize(id)) + "Handler"
+}
+
+func handlerVarName(id string) string {
+	return varfmt.PrivateVarName(normalize(id)) + "Handler"
+}
+
+// HandlerInterface returns go interface representation of resource actions
+func HandlerInterface(id string, actions []Action, op FormatOption) []byte {
+	name := handlerInterfaceName(id)
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// %s handles actions for %s resource\n", name, id)
+	fmt.Fprintf(&src, "type %s interface {\n", name)
+	for _, a := range actions {
+		fmt.Fprintf(&src, "// %s %s: %s\n", a.Name(op), a.Method, a.Href)
+		fmt.Fprintf(&src, "%s(%s) (*%s, error)\n", a.Name(op), a.MethodArgs(op), a.ResponseStructName(op))
+	}
+	fmt.Fprint(&src, "}\n\n")
+	return src.Bytes()
+}
+
+// Route returns go route representation of action
+func (a *Action) Route(id string, op FormatOption) []byte {
+	args := []string{"r.Context()"}
+	var vars []string
+	for _, p := range a.PathParams {
+		args = append(args, p.VarName())
+		vars = append(vars, p.VarName())
+	}
+	var decode string
+	if a.Request != nil {
+		args = append(args, "&params")
+		switch {
+		case a.Method == "GET":
+			decode = "decodeQuery"
+		case a.Encoding == "application/x-www-form-urlencoded":
+			decode = "decodeForm"
+		default:
+			decode = "decodeJSON"
+		}
+	}
+	status := "http.StatusOK"
+	if a.Rel == "create" {
+		status = "http.StatusCreated"
+	}
+
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Parse(`{
+		method:  "{{ .Method }}",
+		pattern: regexp.MustCompile(` + "`{{ .Pattern }}`" + `),
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			{{- if .Vars }}
+			{{ .Vars }}, err := {{ .ParsePath }}(r.URL.EscapedPath())
+			if err != nil {
+				writeError(w, http.StatusNotFound, err)
+				return
+			}
+			{{- end }}
+			{{- if .Request }}
+			var params {{ .Request }}
+			if err := {{ .Decode }}(r, &params); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			{{- end }}
+			res, err := {{ .Handler }}.{{ .Name }}({{ .Args }})
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			writeJSON(w, {{ .Status }}, res)
+		},
+	},
+	`)
+	var request string
+	if a.Request != nil {
+		request = a.RequestStructName(op)
+	}
+	var src bytes.Buffer
+	tmpl.Execute(&src, map[string]string{
+		"Method":    a.Method,
+		"Pattern":   hrefPattern(a.Href),
+		"Vars":      strings.Join(vars, ", "),
+		"ParsePath": a.ParsePathFuncName(op),
+		"Request":   request,
+		"Decode":    decode,
+		"Handler":   handlerVarName(id),
+		"Name":      a.Name(op),
+		"Args":      strings.Join(args, ", "),
+		"Status":    status,
+	})
+	return src.Bytes()
+}
+
+// Server returns go server representation of resource actions
+func Server(links map[string][]Action, op FormatOption) []byte {
+	var ids []string
+	for id, actions := range links {
+		if len(actions) != 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var src bytes.Buffer
+	src.Write(importDecl(serverImports))
+	src.WriteString(serverBase)
+	for _, id := range ids {
+		src.Write(HandlerInterface(id, links[id], op))
+	}
+
+	type entry struct {
+		id     string
+		action Action
+	}
+	var entries []entry
+	var args []string
+	for _, id := range ids {
+		args = append(args, fmt.Sprintf("%s %s", handlerVarName(id), handlerInterfaceName(id)))
+		for _, a := range links[id] {
+			entries = append(entries, entry{id: id, action: a})
+		}
+	}
+	// static routes have to be matched before parameterized ones
+	sort.SliceStable(entries, func(i, j int) bool {
+		return len(entries[i].action.PathParams) < len(entries[j].action.PathParams)
+	})
+
+	fmt.Fprint(&src, "// NewRouter creates router dispatching requests to handlers\n")
+	fmt.Fprintf(&src, "func NewRouter(%s) *Router {\n", strings.Join(args, ", "))
+	fmt.Fprint(&src, "return &Router{routes: []route{\n")
+	for _, e := range entries {
+		src.Write(e.action.Route(e.id, op))
+	}
+	fmt.Fprint(&src, "}}\n}\n")
+	return src.Bytes()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+)
+
+func serverTestLinks() map[string][]Action {
+	task := &Resource{Name: "task", IsPrimary: true}
+	return map[string][]Action{
+		"task": {
+			{
+				Encoding: "application/json",
+				Href:     "/tasks/{(#/definitions/task/definitions/identity)}",
+				Method:   "GET",
+				Rel:      "self",
+				PathParams: []PathParam{
+					{Name: "id", Reference: "#/definitions/task/definitions/identity"},
+				},
+				Response: task,
+			},
+			{
+				Encoding: "application/json",
+				Href:     "/tasks",
+				Method:   "POST",
+				Rel:      "create",
+				Request:  &Resource{Name: "task"},
+				Response: task,
+			},
+			{
+				Encoding: "application/json",
+				Href:     "/tasks",
+				Method:   "GET",
+				Rel:      "instances",
+				Request:  &Resource{Name: "task"},
+				Response: task,
+			},
+			{
+				Encoding: "application/x-www-form-urlencoded",
+				Href:     "/tasks/{(#/definitions/task/definitions/identity)}",
+				Method:   "PATCH",
+				Rel:      "update",
+				PathParams: []PathParam{
+					{Name: "id", Reference: "#/definitions/task/definitions/identity"},
+				},
+				Request:  &Resource{Name: "task"},
+				Response: task,
+			},
+		},
+	}
+}
+
+// trimLines removes indentation to compare snippets of generated code
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestHandlerInterface(t *testing.T) {
+	src := append([]byte("package main\n"), HandlerInterface("task", serverTestLinks()["task"], FormatOption{})...)
+	ss, err := format.Source(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `// TaskHandler handles actions for task resource
+type TaskHandler interface {
+	// TaskSelf GET: /tasks/{(#/definitions/task/definitions/identity)}
+	TaskSelf(ctx context.Context, id string) (*TaskSelfResponse, error)
+	// TaskCreate POST: /tasks
+	TaskCreate(ctx context.Context, params *TaskCreateRequest) (*TaskCreateResponse, error)
+	// TaskInstances GET: /tasks
+	TaskInstances(ctx context.Context, params *TaskInstancesRequest) (*TaskInstancesResponse, error)
+	// TaskUpdate PATCH: /tasks/{(#/definitions/task/definitions/identity)}
+	TaskUpdate(ctx context.Context, id string, params *TaskUpdateRequest) (*TaskUpdateResponse, error)
+}
+`
+	if !strings.HasSuffix(string(ss), expected) {
+		t.Errorf("want %s got %s", expected, ss)
+	}
+}
+
+func TestServer(t *testing.T) {
+	src := append([]byte("package main\n"), Server(serverTestLinks(), FormatOption{})...)
+	ss, err := format.Source(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := trimLines(string(ss))
+
+	if !strings.Contains(out, "func NewRouter(taskHandler TaskHandler) *Router {") {
+		t.Errorf("NewRouter is not generated: %s", out)
+	}
+	// static routes come first, keeping order of links
+	var names []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "res, err := taskHandler.") {
+			names = append(names, line[len("res, err := taskHandler."):strings.Index(line, "(")])
+		}
+	}
+	expected := []string{"TaskCreate", "TaskInstances", "TaskSelf", "TaskUpdate"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("want routes %v got %v", expected, names)
+	}
+
+	for _, s := range []string{
+		`res, err := taskHandler.TaskCreate(r.Context(), &params)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, res)`,
+		`var params TaskInstancesRequest
+			if err := decodeQuery(r, &params); err != nil {`,
+		`var params TaskCreateRequest
+			if err := decodeJSON(r, &params); err != nil {`,
+		`id, err := ParseTaskUpdatePath(r.URL.EscapedPath())
+			if err != nil {
+				writeError(w, http.StatusNotFound, err)
+				return
+			}
+			var params TaskUpdateRequest
+			if err := decodeForm(r, &params); err != nil {`,
+		`res, err := taskHandler.TaskSelf(r.Context(), id)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, res)`,
+	} {
+		if !strings.Contains(out, trimLines(s)) {
+			t.Errorf("want %s got %s", s, out)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	schema "github.com/lestrrat-go/jsschema"
+	"github.com/pkg/errors"
+)
+
+var versionSuffixRe = regexp.MustCompile(`^v[0-9]+$|\.v[0-9]+$`)
+
+// GoType go type and its import path
+type GoType struct {
+	Import  string
+	Package string
+	Expr    string
+}
+
+// ParseGoType parses qualified go type, e.g. github.com/google/uuid.UUID, *net/url.URL
+func ParseGoType(spec string) (GoType, error) {
+	var ptr string
+	if strings.HasPrefix(spec, "*") {
+		ptr = "*"
+		spec = spec[1:]
+	}
+	i := strings.LastIndex(spec, ".")
+	if i < 0 {
+		// builtin or type in the same package
+		return GoType{Expr: ptr + spec}, nil
+	}
+	path, name := spec[:i], spec[i+1:]
+	if path == "" || name == "" || strings.HasSuffix(path, "/") {
+		return GoType{}, errors.Errorf("invalid go type: %s", spec)
+	}
+	pkg := packageName(path)
+	return GoType{
+		Import:  path,
+		Package: pkg,
+		Expr:    ptr + pkg + "." + name,
+	}, nil
+}
+
+// packageName guesses package name from import path,
+// e.g. gopkg.in/guregu/null.v3 -> null, github.com/satori/go.uuid -> uuid
+func packageName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if versionSuffixRe.MatchString(name) && len(elems) > 1 && !strings.Contains(name, ".") {
+		name = elems[len(elems)-2]
+	}
+	name = versionSuffixRe.ReplaceAllString(name, "")
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimPrefix(name, "go.")
+	return strings.Replace(strings.Replace(name, "-", "_", -1), ".", "_", -1)
+}
+
+// ImportSpec returns import spec of go type, aliased if package name differs from path
+func (gt GoType) ImportSpec() string {
+	if gt.Import == "" {
+		return ""
+	}
+	elems := strings.Split(gt.Import, "/")
+	if elems[len(elems)-1] == gt.Package {
+		return fmt.Sprintf("%q", gt.Import)
+	}
+	return fmt.Sprintf("%s %q", gt.Package, gt.Import)
+}
+
+// TypeMapping go types mapped from JSON Schema format
+type TypeMapping struct {
+	Type GoType
+	// NullType is used for optional values unless optional mode is none or generic.
+	// Type wrapped by optional mode is used if not set.
+	NullType GoType
+}
+
+// nullType returns go type expression for optional value
+func (tm TypeMapping) nullType(o Optional) string {
+	if tm.NullType.Expr != "" && o != OptionalNone && o != OptionalGeneric {
+		return tm.NullType.Expr
+	}
+	return o.wrap(tm.Type.Expr)
+}
+
+// TypeMap JSON Schema format to go type mapping
+type TypeMap map[string]TypeMapping
+
+var defaultTypeMap = TypeMap{
+	"date-time": {
+		Type: GoType{Import: "time", Package: "time", Expr: "time.Time"},
+	},
+}
+
+// ParseTypeMap parses format=type[,nulltype] specs, e.g.
+// uuid=github.com/google/uuid.UUID,github.com/google/uuid.NullUUID
+func ParseTypeMap(specs []string) (TypeMap, error) {
+	tm := make(TypeMap)
+	for _, spec := range specs {
+		kv := strings.SplitN(spec, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, errors.Errorf("invalid type mapping, has to be format=type: %s", spec)
+		}
+		types := strings.SplitN(kv[1], ",", 2)
+		var (
+			m   TypeMapping
+			err error
+		)
+		m.Type, err = ParseGoType(types[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(types) == 2 {
+			m.NullType, err = ParseGoType(types[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		tm[kv[0]] = m
+	}
+	return tm, nil
+}
+
+// lookup returns go types of format, falls back to default mapping
+func (tm TypeMap) lookup(format string) (TypeMapping, bool) {
+	if format == "" {
+		return TypeMapping{}, false
+	}
+	if m, ok := tm[format]; ok {
+		return m, true
+	}
+	m, ok := defaultTypeMap[format]
+	return m, ok
+}
+
+// Imports returns import specs of mapped go types and extra types used in src.
+// default mappings are left to goimports
+func (tm TypeMap) Imports(src []byte, extra ...GoType) []string {
+	specs := make(map[string]bool)
+	add := func(gt GoType) {
+		if gt.Import != "" && bytes.Contains(src, []byte(gt.Package+".")) {
+			specs[gt.ImportSpec()] = true
+		}
+	}
+	for _, mp := range tm {
+		add(mp.Type)
+		add(mp.NullType)
+	}
+	for _, gt := range extra {
+		add(gt)
+	}
+	var imports []string
+	for s := range specs {
+		imports = append(imports, s)
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// goTypeOverride returns go type specified by x-go-type of property schema,
+// or of referenced schema. returns nil if not specified
+func goTypeOverride(schemas ...*schema.Schema) (*GoType, error) {
+	for _, sc := range schemas {
+		v, ok := sc.Extras["x-go-type"]
+		if !ok {
+			continue
+		}
+		spec, ok := v.(string)
+		if !ok || spec == "" {
+			return nil, errors.Errorf("x-go-type has to be string: %v", v)
+		}
+		gt, err := ParseGoType(spec)
+		if err != nil {
+			return nil, err
+		}
+		return &gt, nil
+	}
+	return nil, nil
+}
+
+// collectGoTypes returns go types overridden by x-go-type in properties
+func collectGoTypes(props []*Property) []GoType {
+	var types []GoType
+	for _, p := range props {
+		if p.GoTypeOverride != nil {
+			types = append(types, *p.GoTypeOverride)
+		}
+		types = append(types, collectGoTypes(p.InlineProperties)...)
+		if p.AdditionalProperties != nil {
+			types = append(types, collectGoTypes([]*Property{p.AdditionalProperties})...)
+		}
+		if p.Union != nil {
+			for _, v := range p.Union.Variants {
+				types = append(types, collectGoTypes([]*Property{v.Property})...)
+			}
+		}
+	}
+	return types
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func TestParseGoType(t *testing.T) {
+	cases := []struct {
+		Spec     string
+		Expected GoType
+		Import   string
+	}{
+		{
+			Spec:     "github.com/google/uuid.UUID",
+			Expected: GoType{Import: "github.com/google/uuid", Package: "uuid", Expr: "uuid.UUID"},
+			Import:   `"github.com/google/uuid"`,
+		},
+		{
+			Spec:     "*net/url.URL",
+			Expected: GoType{Import: "net/url", Package: "url", Expr: "*url.URL"},
+			Import:   `"net/url"`,
+		},
+		{
+			Spec:     "gopkg.in/guregu/null.v3.String",
+			Expected: GoType{Import: "gopkg.in/guregu/null.v3", Package: "null", Expr: "null.String"},
+			Import:   `null "gopkg.in/guregu/null.v3"`,
+		},
+		{
+			Spec:     "github.com/satori/go.uuid.UUID",
+			Expected: GoType{Import: "github.com/satori/go.uuid", Package: "uuid", Expr: "uuid.UUID"},
+			Import:   `uuid "github.com/satori/go.uuid"`,
+		},
+		{
+			Spec:     "github.com/jackc/pgx/v5/pgtype.Text",
+			Expected: GoType{Import: "github.com/jackc/pgx/v5/pgtype", Package: "pgtype", Expr: "pgtype.Text"},
+			Import:   `"github.com/jackc/pgx/v5/pgtype"`,
+		},
+		{
+			Spec:     "string",
+			Expected: GoType{Expr: "string"},
+			Import:   "",
+		},
+	}
+	for _, c := range cases {
+		gt, err := ParseGoType(c.Spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(gt, c.Expected) {
+			t.Errorf("want %+v got %+v", c.Expected, gt)
+		}
+		if s := gt.ImportSpec(); s != c.Import {
+			t.Errorf("want %s got %s", c.Import, s)
+		}
+	}
+}
+
+func TestParseTypeMap(t *testing.T) {
+	tm, err := ParseTypeMap([]string{
+		"uuid=github.com/google/uuid.UUID,github.com/google/uuid.NullUUID",
+		"decimal=github.com/shopspring/decimal.Decimal",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := tm["uuid"].nullType(OptionalNull); e != "uuid.NullUUID" {
+		t.Errorf("want uuid.NullUUID got %s", e)
+	}
+	if e := tm["uuid"].nullType(OptionalGeneric); e != "Optional[uuid.UUID]" {
+		t.Errorf("want Optional[uuid.UUID] got %s", e)
+	}
+	if e := tm["decimal"].nullType(OptionalNull); e != "*decimal.Decimal" {
+		t.Errorf("want *decimal.Decimal got %s", e)
+	}
+	for _, spec := range []string{"uuid", "=string", "uuid=github.com/.UUID"} {
+		if _, err := ParseTypeMap([]string{spec}); err == nil {
+			t.Errorf("%s: want error", spec)
+		}
+	}
+}
+
+func TestScalarTypeMapping(t *testing.T) {
+	tm, err := ParseTypeMap([]string{"uuid=github.com/google/uuid.UUID"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := []schema.PrimitiveType{schema.StringType}
+	nullStr := []schema.PrimitiveType{schema.StringType, schema.NullType}
+	array := []schema.PrimitiveType{schema.ArrayType}
+	cases := []struct {
+		Property Property
+		Optional Optional
+		Expected string
+	}{
+		{Property: Property{Types: str, Format: "uuid", Required: true}, Expected: "uuid.UUID"},
+		{Property: Property{Types: str, Format: "uuid"}, Expected: "uuid.UUID"},
+		{Property: Property{Types: nullStr, Format: "uuid"}, Optional: OptionalNull, Expected: "*uuid.UUID"},
+		{Property: Property{Types: str, Format: "uuid", Required: true}, Optional: OptionalNull, Expected: "uuid.UUID"},
+		{Property: Property{Types: array, SecondTypes: str, SecondFormat: "uuid"}, Expected: "uuid.UUID"},
+		{Property: Property{Types: str, Format: "date-time"}, Expected: "time.Time"},
+		{Property: Property{Types: nullStr, Format: "date-time"}, Optional: OptionalNull, Expected: "null.Time"},
+		{Property: Property{Types: str, Format: "email"}, Expected: "string"},
+	}
+	for _, c := range cases {
+		op := FormatOption{Optional: c.Optional, TypeMap: tm}
+		if s := c.Property.ScalarType(op); s != c.Expected {
+			t.Errorf("want %s got %s", c.Expected, s)
+		}
+	}
+	imports := tm.Imports([]byte("ID uuid.UUID `json:\"id\"`"))
+	if !reflect.DeepEqual(imports, []string{`"github.com/google/uuid"`}) {
+		t.Errorf("unexpected imports: %v", imports)
+	}
+}
+
+func TestGoTypeOverride(t *testing.T) {
+	df := schema.New()
+	df.Required = []string{"amount"}
+	tp := schema.New()
+	tp.Type = []schema.PrimitiveType{schema.StringType}
+	tp.Extras = map[string]interface{}{"x-go-type": "github.com/shopspring/decimal.Decimal"}
+	p, err := NewProperty("amount", tp, df, df)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Amount decimal.Decimal `json:\"amount\"`"
+	if f := string(p.Field(FormatOption{})); f != expected {
+		t.Errorf("want %s got %s", expected, f)
+	}
+	imports := TypeMap{}.Imports([]byte(expected), collectGoTypes([]*Property{p})...)
+	if !reflect.DeepEqual(imports, []string{`"github.com/shopspring/decimal"`}) {
+		t.Errorf("unexpected imports: %v", imports)
+	}
+
+	tp.Extras["x-go-type"] = 1
+	if _, err := NewProperty("amount", tp, df, df); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/achiku/varfmt"
+	schema "github.com/lestrrat-go/jsschema"
+	"github.com/pkg/errors"
+)
+
+// Union union type for oneOf/anyOf
+type Union struct {
+	Name          string
+	Discriminator string
+	Variants      []*UnionVariant
+}
+
+// UnionVariant variant of union type
+type UnionVariant struct {
+	Name     string
+	Value    interface{}
+	Property *Property
+	Schema   *schema.Schema
+}
+
+// NewUnion creates union from oneOf/anyOf schemas. returns nil if schema is not oneOf/anyOf
+func NewUnion(name string, sc *schema.Schema, root *schema.Schema, exp expansion) (*Union, error) {
+	schemas, kw := sc.OneOf, "oneOf"
+	if len(schemas) == 0 {
+		schemas, kw = sc.AnyOf, "anyOf"
+	}
+	if len(schemas) == 0 {
+		return nil, nil
+	}
+	u := &Union{Name: name}
+	names := make(map[string]bool)
+	var errs ErrorList
+	for i, s := range schemas {
+		rs, err := resolveSchema(s, root)
+		if err != nil {
+			errs.add(errors.Wrap(err, "failed to resolve variant"), kw, strconv.Itoa(i))
+			continue
+		}
+		vname := variantName(s.Reference, rs)
+		if names[vname] {
+			vname = fmt.Sprintf("%s%d", vname, i+1)
+		}
+		names[vname] = true
+		p, err := newProperty(vname, s, rs, root, exp)
+		if err != nil {
+			errs.add(err, kw, strconv.Itoa(i))
+			continue
+		}
+		p.Required = true
+		p.InlineProperties = sortProperties(p.InlineProperties)
+		u.Variants = append(u.Variants, &UnionVariant{
+			Name:     vname,
+			Value:    vname,
+			Property: p,
+			Schema:   rs,
+		})
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	if d, ok := sc.Extras["discriminator"]; ok {
+		// discriminator: kind, or discriminator: {propertyName: kind}
+		switch v := d.(type) {
+		case string:
+			u.Discriminator = v
+		case map[string]interface{}:
+			u.Discriminator, _ = v["propertyName"].(string)
+		}
+	}
+	if u.Discriminator == "" {
+		u.Discriminator = detectDiscriminator(u.Variants, root)
+	}
+	if u.Discriminator != "" {
+		for _, v := range u.Variants {
+			if val, ok := singleEnumValue(v.Schema, u.Discriminator, root); ok {
+				v.Value = val
+			}
+		}
+	}
+	return u, nil
+}
+
+// variantName returns name of variant from reference, title or type
+func variantName(ref string, sc *schema.Schema) string {
+	switch {
+	case ref != "":
+		return normalize(ref[strings.LastIndex(ref, "/")+1:])
+	case sc.Title != "":
+		return normalize(sc.Title)
+	case len(sc.Type) != 0:
+		return sc.Type[0].String()
+	default:
+		return "variant"
+	}
+}
+
+// singleEnumValue returns value of property if it is fixed by single value enum
+func singleEnumValue(sc *schema.Schema, name string, root *schema.Schema) (interface{}, bool) {
+	prop, ok := sc.Properties[name]
+	if !ok {
+		return nil, false
+	}
+	rs, err := resolveSchema(prop, root)
+	if err != nil || len(rs.Enum) != 1 {
+		return nil, false
+	}
+	return rs.Enum[0], true
+}
+
+// detectDiscriminator returns property fixed to distinct value in every object variant
+func detectDiscriminator(variants []*UnionVariant, root *schema.Schema) string {
+	if len(variants) == 0 {
+		return ""
+	}
+	var names []string
+	for n := range variants[0].Schema.Properties {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		seen := make(map[string]bool)
+		for _, v := range variants {
+			val, ok := singleEnumValue(v.Schema, n, root)
+			if !ok || seen[fmt.Sprint(val)] {
+				seen = nil
+				break
+			}
+			seen[fmt.Sprint(val)] = true
+		}
+		if seen != nil {
+			return n
+		}
+	}
+	return ""
+}
+
+// TypeName returns go type name of union
+func (u *Union) TypeName() string {
+	return varfmt.PublicVarName(u.Name)
+}
+
+// VariantTypeName returns go type name of union variant
+func (u *Union) VariantTypeName(v *UnionVariant) string {
+	return u.TypeName() + varfmt.PublicVarName(v.Name)
+}
+
+// variantType returns go type definition of union variant
+func (u *Union) variantType(v *UnionVariant, op FormatOption) string {
+	p := v.Property
+	switch {
+	case p.PropType == PropTypeObject && p.IsRefToMainResource():
+		return varfmt.PublicVarName(normalize(p.refToStructName()))
+	case p.PropType == PropTypeObject && p.RecursiveType != "":
+		return varfmt.PublicVarName(p.RecursiveType)
+	case p.PropType == PropTypeObject && len(p.InlineProperties) == 0:
+		return "map[string]interface{}"
+	default:
+		return p.GoType(op)
+	}
+}
+
+// signature returns discriminator, and name, type and discriminator value of
+// variants. unions generated as the same type have to have the same signature
+func (u *Union) signature() string {
+	sig := []string{u.Discriminator}
+	for _, v := range u.Variants {
+		b, _ := json.Marshal(v.Value)
+		sig = append(sig, fmt.Sprintf("%s %s %s", u.VariantTypeName(v), u.variantType(v, FormatOption{}), b))
+	}
+	return strings.Join(sig, "\n")
+}
+
+// Type returns go type representation of union
+func (u *Union) Type(op FormatOption) []byte {
+	type variant struct {
+		Name    string
+		Type    string
+		Literal string
+	}
+	var (
+		variants []variant
+		names    []string
+	)
+	for _, v := range u.Variants {
+		b, _ := json.Marshal(v.Value)
+		variants = append(variants, variant{
+			Name:    u.VariantTypeName(v),
+			Type:    u.variantType(v, op),
+			Literal: strconv.Quote(string(b)),
+		})
+		names = append(names, u.VariantTypeName(v))
+	}
+	// ignore errors since it always succeeds
+	tmpl, _ := template.New("").Parse(`
+// {{ .Name }} holds one of {{ .Names }}
+type {{ .Name }} struct {
+	Variant {{ .Name }}Variant
+}
+
+// {{ .Name }}Variant is implemented by variants of {{ .Name }}
+type {{ .Name }}Variant interface {
+	is{{ .Name }}()
+}
+{{ range .Variants }}
+// {{ .Name }} variant of {{ $.Name }}
+type {{ .Name }} {{ .Type }}
+
+func ({{ .Name }}) is{{ $.Name }}() {}
+{{ end }}
+// MarshalJSON encodes variant of {{ .Name }}
+func (u {{ .Name }}) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.Variant)
+}
+
+// UnmarshalJSON decodes variant of {{ .Name }}
+func (u *{{ .Name }}) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		u.Variant = nil
+		return nil
+	}
+	{{- if .Discriminator }}
+	var d struct {
+		Value json.RawMessage ` + "`json:\"{{ .Discriminator }}\"`" + `
+	}
+	if err := json.Unmarshal(b, &d); err != nil {
+		return err
+	}
+	switch string(d.Value) {
+	{{- range .Variants }}
+	case {{ .Literal }}:
+		var v {{ .Name }}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		u.Variant = v
+	{{- end }}
+	default:
+		return fmt.Errorf("unknown {{ .Discriminator }} of {{ .Name }}: %s", d.Value)
+	}
+	return nil
+	{{- else }}
+	{{- range .Variants }}
+	{
+		var v {{ .Name }}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&v); err == nil {
+			u.Variant = v
+			return nil
+		}
+	}
+	{{- end }}
+	return fmt.Errorf("no variant of {{ .Name }} matches: %s", b)
+	{{- end }}
+}
+`)
+	var src bytes.Buffer
+	tmpl.Execute(&src, map[string]interface{}{
+		"Name":          u.TypeName(),
+		"Names":         strings.Join(names, ", "),
+		"Discriminator": u.Discriminator,
+		"Variants":      variants,
+	})
+	return src.Bytes()
+}
+
+// sortUnions returns unions sorted by type name
+func sortUnions(unions map[string]*Union) []*Union {
+	var names []string
+	for n := range unions {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var sorted []*Union
+	for _, n := range names {
+		sorted = append(sorted, unions[n])
+	}
+	return sorted
+}
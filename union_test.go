@@ -0,0 +1,204 @@
+package main
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+func testUnion(discriminator string) *Union {
+	return &Union{
+		Name:          "task_target",
+		Discriminator: discriminator,
+		Variants: []*UnionVariant{
+			{
+				Name:  "user",
+				Value: "user",
+				Property: &Property{
+					Name:      "user",
+					Types:     []schema.PrimitiveType{schema.ObjectType},
+					PropType:  PropTypeObject,
+					Reference: "#/definitions/user",
+					Required:  true,
+				},
+			},
+			{
+				Name:  "team",
+				Value: "team",
+				Property: &Property{
+					Name:     "team",
+					Types:    []schema.PrimitiveType{schema.ObjectType},
+					PropType: PropTypeObject,
+					Required: true,
+					InlineProperties: []*Property{
+						{
+							Name:     "kind",
+							Types:    []schema.PrimitiveType{schema.StringType},
+							Required: true,
+						},
+						{
+							Name:     "members",
+							Types:    []schema.PrimitiveType{schema.ArrayType},
+							PropType: PropTypeArray,
+							SecondTypes: []schema.PrimitiveType{
+								schema.StringType,
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:  "string",
+				Value: "string",
+				Property: &Property{
+					Name:     "string",
+					Types:    []schema.PrimitiveType{schema.StringType},
+					Required: true,
+				},
+			},
+		},
+	}
+}
+
+func TestUnionType(t *testing.T) {
+	cases := []struct {
+		discriminator string
+		expected      []string
+	}{
+		{
+			expected: []string{
+				`
+// TaskTarget holds one of TaskTargetUser, TaskTargetTeam, TaskTargetString
+type TaskTarget struct {
+	Variant TaskTargetVariant
+}
+`,
+				`
+// TaskTargetTeam variant of TaskTarget
+type TaskTargetTeam struct {
+	Kind    string   ` + "`json:\"kind\"`" + `
+	Members []string ` + "`json:\"members,omitempty\"`" + `
+}
+
+func (TaskTargetTeam) isTaskTarget() {}
+`,
+				`
+	{
+		var v TaskTargetString
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&v); err == nil {
+			u.Variant = v
+			return nil
+		}
+	}
+	return fmt.Errorf("no variant of TaskTarget matches: %s", b)
+}
+`,
+			},
+		},
+		{
+			discriminator: "kind",
+			expected: []string{
+				`
+	switch string(d.Value) {
+	case "\"user\"":
+		var v TaskTargetUser
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		u.Variant = v
+`,
+				`
+	default:
+		return fmt.Errorf("unknown kind of TaskTarget: %s", d.Value)
+	}
+	return nil
+}
+`,
+			},
+		},
+	}
+	for _, c := range cases {
+		// formatted as fragment like struct command, so that indentation is kept
+		ss, err := format.Source(testUnion(c.discriminator).Type(FormatOption{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range c.expected {
+			if !strings.Contains(string(ss), expected) {
+				t.Errorf("want %s got %s", expected, ss)
+			}
+		}
+	}
+}
+
+func TestUnionField(t *testing.T) {
+	cases := []struct {
+		Prop     Property
+		Expected string
+	}{
+		{
+			Prop: Property{
+				Name:     "target",
+				PropType: PropTypeUnion,
+				Union:    testUnion(""),
+				Required: true,
+			},
+			Expected: "Target TaskTarget `json:\"target\"`",
+		},
+		{
+			Prop: Property{
+				Name:     "target",
+				PropType: PropTypeUnion,
+				Union:    testUnion(""),
+			},
+			Expected: "Target *TaskTarget `json:\"target,omitempty\"`",
+		},
+		{
+			Prop: Property{
+				Name:     "targets",
+				Types:    []schema.PrimitiveType{schema.ArrayType},
+				PropType: PropTypeArray,
+				Union:    testUnion(""),
+				Required: true,
+			},
+			Expected: "Targets []TaskTarget `json:\"targets\"`",
+		},
+	}
+	for _, c := range cases {
+		if f := c.Prop.Field(FormatOption{}); string(f) != c.Expected {
+			t.Errorf("want %s got %s", c.Expected, f)
+		}
+	}
+}
+
+func TestCollectTypesUnionConflict(t *testing.T) {
+	cases := []struct {
+		modify func(u *Union)
+		err    bool
+	}{
+		{modify: func(u *Union) {}, err: false},
+		{modify: func(u *Union) { u.Variants[2].Property.Types = []schema.PrimitiveType{schema.IntegerType} }, err: true},
+		{modify: func(u *Union) { u.Variants[0].Name = "owner" }, err: true},
+		{modify: func(u *Union) { u.Variants[1].Value = "group" }, err: true},
+		{modify: func(u *Union) { u.Discriminator = "type" }, err: true},
+	}
+	for i, c := range cases {
+		other := testUnion("kind")
+		c.modify(other)
+		props := []*Property{
+			{Name: "target", PropType: PropTypeUnion, Union: testUnion("kind")},
+			{Name: "source", PropType: PropTypeUnion, Union: other},
+		}
+		err := collectTypes(props, make(map[string]*Enum), make(map[string]*Union), make(map[string]*Property))
+		if c.err && (err == nil || err.Error() != "union TaskTarget is defined with different variants or discriminator") {
+			t.Errorf("%d: want conflict error got %v", i, err)
+		}
+		if !c.err && err != nil {
+			t.Errorf("%d: want nil got %s", i, err)
+		}
+	}
+}
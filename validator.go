@@ -4,20 +4,40 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/achiku/varfmt"
+	schema "github.com/lestrrat-go/jsschema"
+	"github.com/pkg/errors"
 )
 
 // Validators validators
 type Validators map[string]Validator
 
+// sorted returns validators sorted by name for deterministic output
+func (vs Validators) sorted() []Validator {
+	var names []string
+	for n := range vs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var sorted []Validator
+	for _, n := range names {
+		sorted = append(sorted, vs[n])
+	}
+	return sorted
+}
+
 // Render rendor validators
 func (vs Validators) Render() []byte {
+	sorted := vs.sorted()
 	var src bytes.Buffer
 	// constants
 	fmt.Fprint(&src, "// regexp string constants\n")
 	fmt.Fprint(&src, "const (\n")
-	for _, v := range vs {
+	for _, v := range sorted {
 		fmt.Fprintf(&src, "%s\n", v.RegexpConst())
 	}
 	fmt.Fprint(&src, ")\n")
@@ -25,7 +45,7 @@ func (vs Validators) Render() []byte {
 	// vars
 	fmt.Fprint(&src, "// regexp objects\n")
 	fmt.Fprint(&src, "var (\n")
-	for _, v := range vs {
+	for _, v := range sorted {
 		fmt.Fprintf(&src, "%s\n", v.RegexpVar())
 	}
 	fmt.Fprint(&src, ")\n")
@@ -34,20 +54,52 @@ func (vs Validators) Render() []byte {
 	fmt.Fprint(&src, "var validate *validator.Validate\n")
 
 	// function definitions
-	for _, v := range vs {
+	for _, v := range sorted {
 		fmt.Fprintf(&src, "%s\n", v.ValidatorFunc())
 	}
 
 	// register validation functions
-	fmt.Fprint(&src, "func init() {\n")
+	fmt.Fprint(&src, "\nfunc init() {\n")
 	fmt.Fprint(&src, "validate = validator.New()\n")
-	for _, v := range vs {
+	for _, v := range sorted {
 		fmt.Fprintf(&src, "%s\n", v.RegisterFunc())
 	}
 	fmt.Fprint(&src, "}\n")
 	return src.Bytes()
 }
 
+// collect adds pattern validators of properties including inline objects. returns
+// error if validators of the same go name have different patterns
+func (vs Validators) collect(props []*Property) error {
+	for _, p := range props {
+		if p.Pattern != nil && p.ValidatorName != "" && p.PropType == PropTypeScalar {
+			v := Validator{
+				Name:         p.ValidatorName,
+				RegexpString: p.Pattern.String(),
+			}
+			key := v.ValidateFuncName()
+			if ex, ok := vs[key]; ok && ex.RegexpString != v.RegexpString {
+				return errors.Errorf("validator %s is generated for different patterns: %s `%s` and %s `%s`",
+					key, ex.Name, ex.RegexpString, v.Name, v.RegexpString)
+			}
+			vs[key] = v
+		}
+		if err := vs.collect(p.InlineProperties); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nameValidators names pattern validators of properties by resource and property path,
+// e.g. TaskIDValidator
+func nameValidators(props []*Property, prefix string) {
+	for _, p := range props {
+		p.ValidatorName = prefix + "_" + normalize(p.Name)
+		nameValidators(p.InlineProperties, p.ValidatorName)
+	}
+}
+
 // Validator validator
 type Validator struct {
 	Name         string
@@ -103,3 +155,143 @@ func (val Validator) RegisterFunc() string {
 	tmpl.Execute(&src, val)
 	return src.String()
 }
+
+// formatTags go-playground/validator tags of well-known formats
+var formatTags = map[string]string{
+	"email": "email",
+	"uri":   "uri",
+	"uuid":  "uuid",
+}
+
+// ValidateTag returns go-playground/validator tag of property. pattern is validated
+// by custom validator named patternValidator
+func (pr *Property) ValidateTag(patternValidator string, op FormatOption) string {
+	// null is valid value of required nullable property
+	required := pr.Required && !pr.Types.Contains(schema.NullType) &&
+		(pr.Enum == nil || !pr.Enum.Nullable)
+	var tags []string
+	if required {
+		tags = append(tags, "required")
+	}
+	rules := pr.validateRules(patternValidator, op)
+	if len(rules) == 0 {
+		return strings.Join(tags, ",")
+	}
+	if !required {
+		tags = append(tags, "omitempty")
+	}
+	return strings.Join(append(tags, rules...), ",")
+}
+
+// validateRules returns validator rules of property constraints
+func (pr *Property) validateRules(patternValidator string, op FormatOption) []string {
+	if pr.GoTypeOverride != nil {
+		return nil
+	}
+	switch pr.PropType {
+	case PropTypeScalar:
+		if !validatableType(pr.GoType(op), pr.Enum) {
+			return nil
+		}
+		var pattern string
+		if pr.Pattern != nil {
+			pattern = patternValidator
+		}
+		return scalarRules(pr.Schema, pr.Enum, pattern)
+	case PropTypeArray:
+		t := pr.GoType(op)
+		if !strings.HasPrefix(t, "[]") {
+			// wrapped by optional type
+			return nil
+		}
+		var rules []string
+		if sc := pr.Schema; sc != nil {
+			if sc.MinItems.Initialized {
+				rules = append(rules, fmt.Sprintf("min=%d", sc.MinItems.Val))
+			}
+			if sc.MaxItems.Initialized {
+				rules = append(rules, fmt.Sprintf("max=%d", sc.MaxItems.Val))
+			}
+			if sc.UniqueItems.Val {
+				rules = append(rules, "unique")
+			}
+		}
+		switch {
+		case pr.Union != nil:
+		case pr.SecondTypes.Contains(schema.ObjectType):
+			// validate struct elements
+			rules = append(rules, "dive")
+		case validatableType(pr.ScalarType(op), pr.Enum):
+			if items := scalarRules(pr.ItemSchema, pr.Enum, ""); len(items) != 0 {
+				rules = append(append(rules, "dive"), items...)
+			}
+		}
+		return rules
+	default:
+		// nested structs are validated without rules
+		return nil
+	}
+}
+
+// validatableType returns true if go type t is validated by validator rules as is
+func validatableType(t string, enum *Enum) bool {
+	t = strings.TrimPrefix(t, "*")
+	switch {
+	case enum != nil && t == enum.TypeName():
+		return true
+	case t == "string" || t == "int64" || t == "float64":
+		return true
+	default:
+		return false
+	}
+}
+
+// scalarRules returns validator rules of scalar schema constraints
+func scalarRules(sc *schema.Schema, enum *Enum, patternValidator string) []string {
+	var rules []string
+	switch {
+	case sc == nil:
+	case sc.Type.Contains(schema.StringType):
+		if sc.MinLength.Initialized {
+			rules = append(rules, fmt.Sprintf("min=%d", sc.MinLength.Val))
+		}
+		if sc.MaxLength.Initialized {
+			rules = append(rules, fmt.Sprintf("max=%d", sc.MaxLength.Val))
+		}
+		if tag, ok := formatTags[string(sc.Format)]; ok {
+			rules = append(rules, tag)
+		}
+	case sc.Type.Contains(schema.IntegerType) || sc.Type.Contains(schema.NumberType):
+		if sc.Minimum.Initialized {
+			op := "gte"
+			if sc.ExclusiveMinimum.Val {
+				op = "gt"
+			}
+			rules = append(rules, op+"="+strconv.FormatFloat(sc.Minimum.Val, 'f', -1, 64))
+		}
+		if sc.Maximum.Initialized {
+			op := "lte"
+			if sc.ExclusiveMaximum.Val {
+				op = "lt"
+			}
+			rules = append(rules, op+"="+strconv.FormatFloat(sc.Maximum.Val, 'f', -1, 64))
+		}
+	}
+	if enum != nil {
+		var values []string
+		for _, v := range enum.Values {
+			s := strings.Trim(enum.literal(v), `"`)
+			// comma and pipe are separators of validator tag
+			s = strings.NewReplacer(",", "0x2C", "|", "0x7C").Replace(s)
+			if strings.Contains(s, " ") {
+				s = "'" + s + "'"
+			}
+			values = append(values, s)
+		}
+		rules = append(rules, "oneof="+strings.Join(values, " "))
+	}
+	if patternValidator != "" {
+		rules = append(rules, patternValidator)
+	}
+	return rules
+}
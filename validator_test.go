@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	schema "github.com/lestrrat-go/jsschema"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestValidatorsRenderGolden(t *testing.T) {
+	var first []byte
+	// schema is parsed every run, since iteration order of maps differs
+	for i := 0; i < 20; i++ {
+		fp, err := os.Open(filepath.Join("testdata", "validator.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := ReadParser(fp, "model")
+		fp.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		vals, err := p.ParseValidators()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ss, err := format.Source(vals.Render())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = ss
+		} else if !bytes.Equal(first, ss) {
+			t.Fatalf("output differs between runs:\n%s\n%s", first, ss)
+		}
+	}
+
+	golden := filepath.Join("testdata", "validator.golden")
+	if *update {
+		if err := ioutil.WriteFile(golden, first, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, expected) {
+		t.Errorf("want %s got %s", expected, first)
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	str := []schema.PrimitiveType{schema.StringType}
+	integer := []schema.PrimitiveType{schema.IntegerType}
+	array := []schema.PrimitiveType{schema.ArrayType}
+	object := []schema.PrimitiveType{schema.ObjectType}
+
+	name := schema.New()
+	name.Type = str
+	name.MinLength = schema.Integer{Val: 1, Initialized: true}
+	name.MaxLength = schema.Integer{Val: 30, Initialized: true}
+
+	email := schema.New()
+	email.Type = str
+	email.Format = "email"
+
+	age := schema.New()
+	age.Type = integer
+	age.Minimum = schema.Number{Val: 0, Initialized: true}
+	age.Maximum = schema.Number{Val: 150, Initialized: true}
+	age.ExclusiveMaximum = schema.Bool{Val: true, Initialized: true}
+
+	tags := schema.New()
+	tags.Type = array
+	tags.MinItems = schema.Integer{Val: 1, Initialized: true}
+	tags.UniqueItems = schema.Bool{Val: true, Initialized: true}
+	tag := schema.New()
+	tag.Type = str
+	tag.Format = "uuid"
+
+	status := &Enum{Name: "task_status", Types: str, Values: []interface{}{"todo", "in progress", "a,b"}}
+
+	cases := []struct {
+		Property Property
+		Optional Optional
+		Expected string
+	}{
+		{Property: Property{PropType: PropTypeScalar, Types: str, Required: true}, Expected: "required"},
+		{Property: Property{PropType: PropTypeScalar, Types: str}, Expected: ""},
+		{Property: Property{PropType: PropTypeScalar, Types: str, Schema: name, Required: true}, Expected: "required,min=1,max=30"},
+		{Property: Property{PropType: PropTypeScalar, Types: str, Schema: email}, Expected: "omitempty,email"},
+		{Property: Property{PropType: PropTypeScalar, Types: str, Schema: email}, Optional: OptionalPointer, Expected: "omitempty,email"},
+		{Property: Property{PropType: PropTypeScalar, Types: str, Schema: email}, Optional: OptionalNull, Expected: ""},
+		{Property: Property{PropType: PropTypeScalar, Types: integer, Schema: age, Required: true}, Expected: "required,gte=0,lt=150"},
+		{
+			Property: Property{PropType: PropTypeScalar, Types: str, Schema: stringSchema(), Enum: status, Required: true},
+			Expected: "required,oneof=todo 'in progress' a0x2Cb",
+		},
+		{
+			Property: Property{PropType: PropTypeScalar, Types: str, Schema: stringSchema(), Pattern: regexp.MustCompile("^[a-z]+$")},
+			Expected: "omitempty,NameValidator",
+		},
+		{
+			Property: Property{PropType: PropTypeArray, Types: array, SecondTypes: str, Schema: tags, ItemSchema: tag, Required: true},
+			Expected: "required,min=1,unique,dive,uuid",
+		},
+		{
+			Property: Property{PropType: PropTypeArray, Types: array, SecondTypes: object, Schema: tags, InlineProperties: []*Property{}},
+			Expected: "omitempty,min=1,unique,dive",
+		},
+	}
+	for _, c := range cases {
+		if tag := c.Property.ValidateTag("NameValidator", FormatOption{Optional: c.Optional}); tag != c.Expected {
+			t.Errorf("want %s got %s", c.Expected, tag)
+		}
+	}
+}
+
+func stringSchema() *schema.Schema {
+	sc := schema.New()
+	sc.Type = []schema.PrimitiveType{schema.StringType}
+	return sc
+}
+
+func TestValidatorsCollect(t *testing.T) {
+	str := []schema.PrimitiveType{schema.StringType}
+	task := []*Property{
+		&Property{Name: "id", PropType: PropTypeScalar, Types: str, Pattern: regexp.MustCompile(`^t[0-9]+$`)},
+		&Property{
+			Name:     "owner",
+			PropType: PropTypeObject,
+			Types:    []schema.PrimitiveType{schema.ObjectType},
+			InlineProperties: []*Property{
+				&Property{Name: "name", PropType: PropTypeScalar, Types: str, Pattern: regexp.MustCompile(`^[a-z]+$`)},
+			},
+		},
+	}
+	user := []*Property{
+		&Property{Name: "id", PropType: PropTypeScalar, Types: str, Pattern: regexp.MustCompile(`^u[0-9]+$`)},
+	}
+	nameValidators(task, "task")
+	nameValidators(user, "user")
+
+	vals := make(Validators)
+	for _, props := range [][]*Property{task, user} {
+		if err := vals.collect(props); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"TaskIDValidator", "TaskOwnerNameValidator", "UserIDValidator"} {
+		if _, ok := vals[name]; !ok {
+			t.Errorf("%s not found: %v", name, vals)
+		}
+	}
+	if len(vals) != 3 {
+		t.Errorf("want 3 validators got %d", len(vals))
+	}
+	tag := string(task[0].Field(FormatOption{Validator: true}))
+	if expected := "ID string `json:\"id,omitempty\" validate:\"omitempty,TaskIDValidator\"`"; tag != expected {
+		t.Errorf("want %s got %s", expected, tag)
+	}
+
+	// task_user + id and task + user_id generate the same go name
+	taskUser := []*Property{
+		&Property{Name: "id", PropType: PropTypeScalar, Types: str, Pattern: regexp.MustCompile(`^tu[0-9]+$`)},
+	}
+	taskUserID := []*Property{
+		&Property{Name: "user_id", PropType: PropTypeScalar, Types: str, Pattern: regexp.MustCompile(`^[0-9]+$`)},
+	}
+	nameValidators(taskUser, "task_user")
+	nameValidators(taskUserID, "task")
+	if err := vals.collect(taskUser); err != nil {
+		t.Fatal(err)
+	}
+	if err := vals.collect(taskUserID); err == nil {
+		t.Error("want collision error")
+	}
+}